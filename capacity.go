@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"math"
+	"os"
+	"sort"
+)
+
+// capacityHost holds the hardware of a cluster host available to run VMs
+type capacityHost struct {
+	cpuCores int64
+	cpuMhz   int64
+	memory   int64
+}
+
+func GetClusterCapacity(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"ClusterComputeResource"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var clusters []mo.ClusterComputeResource
+
+	err = v.RetrieveWithFilter(ctx, []string{"ClusterComputeResource"}, []string{"name", "configurationEx", "host", "resourcePool"}, &clusters, property.Match{"self.value": clusterFlag})
+
+	if err != nil {
+		return err
+	}
+
+	vmMemory := vmMemoryFlag * 1024 * 1024 * 1024
+
+	fmt.Fprint(os.Stdout, "cluster;name;numHosts;numVms;cpuCores;vCpus;vCpuRatio;totalMemory;allocatedMemory;memoryRatio;reservedCpu;reservedMemory;admissionControlPolicy;failoverCpuCores;failoverMemory;n1CpuHeadroom;n1MemoryHeadroom;vmCpu;vmMemory;maxCpuRatio;vmsFitByCpu;vmsFitByMemory;vmsFit\n")
+
+	clusterFound := false
+	pc := property.DefaultCollector(c)
+	for _, cluster := range clusters {
+		clusterFound = true
+
+		// only connected hosts outside maintenance mode contribute capacity
+		var hosts []capacityHost
+		var vmRefs []types.ManagedObjectReference
+		if len(cluster.Host) > 0 {
+			var hss []mo.HostSystem
+			err = pc.Retrieve(ctx, cluster.Host, []string{"summary", "vm"}, &hss)
+			if err != nil {
+				return err
+			}
+			for _, hs := range hss {
+				vmRefs = append(vmRefs, hs.Vm...)
+				if hs.Summary.Hardware == nil || hs.Summary.Runtime == nil {
+					continue
+				}
+				if hs.Summary.Runtime.ConnectionState != types.HostSystemConnectionStateConnected || hs.Summary.Runtime.InMaintenanceMode {
+					continue
+				}
+				hosts = append(hosts, capacityHost{
+					cpuCores: int64(hs.Summary.Hardware.NumCpuCores),
+					cpuMhz:   int64(hs.Summary.Hardware.CpuMhz),
+					memory:   hs.Summary.Hardware.MemorySize,
+				})
+			}
+		}
+
+		// allocations only count powered on VMs, as those are the ones HA restarts
+		numVms, vCpus, allocatedMemory := 0, int64(0), int64(0)
+		if len(vmRefs) > 0 {
+			var vms []mo.VirtualMachine
+			err = pc.Retrieve(ctx, vmRefs, []string{"summary.config", "summary.runtime.powerState"}, &vms)
+			if err != nil {
+				return err
+			}
+			for _, vm := range vms {
+				if vm.Summary.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+					continue
+				}
+				numVms++
+				vCpus += int64(vm.Summary.Config.NumCpu)
+				allocatedMemory += int64(vm.Summary.Config.MemorySizeMB) * 1024 * 1024
+			}
+		}
+
+		reservedCpu, reservedMemory := int64(0), int64(0)
+		if cluster.ResourcePool != nil {
+			var pool mo.ResourcePool
+			err = pc.RetrieveOne(ctx, *cluster.ResourcePool, []string{"runtime"}, &pool)
+			if err != nil {
+				return err
+			}
+			reservedCpu = pool.Runtime.Cpu.ReservationUsed
+			reservedMemory = pool.Runtime.Memory.ReservationUsed
+		}
+
+		cpuCores, totalMhz, totalMemory := int64(0), int64(0), int64(0)
+		for _, host := range hosts {
+			cpuCores += host.cpuCores
+			totalMhz += host.cpuCores * host.cpuMhz
+			totalMemory += host.memory
+		}
+
+		// the pool CPU reservation is in MHz, converted to cores with the average MHz per core.
+		// Reserved cores cannot be overcommitted, so each one takes maxCpuRatio vCPUs of the budget.
+		reservedVCpus := int64(0)
+		if totalMhz > 0 {
+			reservedCores := float64(reservedCpu) * float64(cpuCores) / float64(totalMhz)
+			reservedVCpus = int64(math.Ceil(reservedCores * maxCpuRatioFlag))
+		}
+
+		policy := "NA"
+		failoverCores, failoverMemory := int64(0), int64(0)
+		if config, ok := cluster.ConfigurationEx.(*types.ClusterConfigInfoEx); ok {
+			failoverCores, failoverMemory, policy = failoverCapacity(config.DasConfig, hosts, cpuCores, totalMemory)
+		}
+
+		// N+1 leaves room to restart the VMs of the largest host
+		largestCores, largestMemory := int64(0), int64(0)
+		for _, host := range hosts {
+			largestCores = max(largestCores, host.cpuCores)
+			largestMemory = max(largestMemory, host.memory)
+		}
+		n1CpuHeadroom := int64(math.Floor(float64(cpuCores-largestCores)*maxCpuRatioFlag)) - vCpus
+		n1MemoryHeadroom := totalMemory - largestMemory - allocatedMemory
+
+		// reservations overlap with VM allocations, so the larger of both is consumed
+		usedVCpus := max(vCpus, reservedVCpus)
+		usedMemory := max(allocatedMemory, reservedMemory)
+		vmsFitByCpu := int64(0)
+		if vmCpuFlag > 0 {
+			vmsFitByCpu = (int64(math.Floor(float64(cpuCores-failoverCores)*maxCpuRatioFlag)) - usedVCpus) / int64(vmCpuFlag)
+		}
+		vmsFitByMemory := int64(0)
+		if vmMemory > 0 {
+			vmsFitByMemory = (totalMemory - failoverMemory - usedMemory) / vmMemory
+		}
+		vmsFitByCpu = max(vmsFitByCpu, 0)
+		vmsFitByMemory = max(vmsFitByMemory, 0)
+
+		vCpuRatio, memoryRatio := 0.0, 0.0
+		if cpuCores > 0 {
+			vCpuRatio = float64(vCpus) / float64(cpuCores)
+		}
+		if totalMemory > 0 {
+			memoryRatio = float64(allocatedMemory) / float64(totalMemory)
+		}
+
+		fmt.Fprintf(os.Stdout, "%s;%s;%d;%d;%d;%d;%.2f;%d;%d;%.2f;%d;%d;%s;%d;%d;%d;%d;%d;%d;%.2f;%d;%d;%d\n",
+			safeValue(cluster.Self.Value),
+			safeValue(cluster.Name),
+			len(hosts),
+			numVms,
+			cpuCores,
+			vCpus,
+			vCpuRatio,
+			totalMemory,
+			allocatedMemory,
+			memoryRatio,
+			reservedCpu,
+			reservedMemory,
+			safeValue(policy),
+			failoverCores,
+			failoverMemory,
+			n1CpuHeadroom,
+			n1MemoryHeadroom,
+			vmCpuFlag,
+			vmMemory,
+			maxCpuRatioFlag,
+			vmsFitByCpu,
+			vmsFitByMemory,
+			min(vmsFitByCpu, vmsFitByMemory),
+		)
+	}
+	if !clusterFound {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Cluster not found.")
+		os.Exit(1)
+	}
+	return nil
+}
+
+// failoverCapacity returns the cpu cores and memory reserved by HA admission control
+func failoverCapacity(das types.ClusterDasConfigInfo, hosts []capacityHost, cpuCores int64, totalMemory int64) (int64, int64, string) {
+	policy, failoverLevel, cpuPercent, memoryPercent := admissionControlPolicy(das)
+	if das.Enabled == nil || !*das.Enabled || das.AdmissionControlEnabled == nil || !*das.AdmissionControlEnabled {
+		return 0, 0, "disabled"
+	}
+
+	if _, ok := das.AdmissionControlPolicy.(*types.ClusterFailoverResourcesAdmissionControlPolicy); ok {
+		return cpuCores * int64(cpuPercent) / 100, totalMemory * int64(memoryPercent) / 100, policy
+	}
+
+	// slot and dedicated host policies keep the largest hosts free
+	cores := make([]int64, 0, len(hosts))
+	memory := make([]int64, 0, len(hosts))
+	for _, host := range hosts {
+		cores = append(cores, host.cpuCores)
+		memory = append(memory, host.memory)
+	}
+	sort.Slice(cores, func(i, j int) bool { return cores[i] > cores[j] })
+	sort.Slice(memory, func(i, j int) bool { return memory[i] > memory[j] })
+
+	failoverCores, failoverMemory := int64(0), int64(0)
+	for i := 0; i < int(failoverLevel) && i < len(hosts); i++ {
+		failoverCores += cores[i]
+		failoverMemory += memory[i]
+	}
+	return failoverCores, failoverMemory, policy
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+func printCertificate(certType string, name string, info *object.HostCertificateInfo, now time.Time) {
+	daysToExpire, expiringSoon := "NA", false
+	if info.NotAfter != nil {
+		days := int(math.Floor(info.NotAfter.Sub(now).Hours() / 24))
+		daysToExpire = fmt.Sprintf("%d", days)
+		expiringSoon = days <= expiringDaysFlag
+	}
+
+	fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%s;%t;%s;%s\n",
+		certType,
+		safeValue(name),
+		safeValue(info.Subject),
+		safeValue(info.Issuer),
+		safeValue(info.NotBefore),
+		safeValue(info.NotAfter),
+		daysToExpire,
+		expiringSoon,
+		safeValue(info.Status),
+		safeValue(info.ThumbprintSHA1),
+	)
+}
+
+// printCertificateError prints an NA row for a certificate that cannot be read, so the other rows are kept
+func printCertificateError(certType string, name string, err error) {
+	fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%s;%t;%s;%s\n",
+		certType,
+		safeValue(name),
+		"NA", "NA", "NA", "NA", "NA", false,
+		safeValue("ERROR:"+strings.ReplaceAll(err.Error(), ";", ",")),
+		"NA",
+	)
+}
+
+func GetCertificates(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"HostSystem"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var hss []mo.HostSystem
+
+	err = v.RetrieveWithFilter(ctx, []string{"HostSystem"}, []string{"name", "config.certificate", "configManager.certificateManager"}, &hss, property.Match{"name": hostFlag})
+	if err != nil {
+		return err
+	}
+
+	now, err := methods.GetCurrentTime(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(os.Stdout, "type;name;subject;issuer;notBefore;notAfter;daysToExpire;expiringSoon;status;thumbprintSHA1\n")
+
+	// the vCenter certificate is read from the TLS handshake, trusted either by CA or known thumbprint
+	var vcenter object.HostCertificateInfo
+	err = vcenter.FromURL(c.URL(), c.DefaultTransport().TLSClientConfig)
+	if err != nil {
+		printCertificateError("vcenter", c.URL().Hostname(), err)
+	} else {
+		if vcenter.Err != nil {
+			vcenter.Status = "untrusted"
+			if c.KnownThumbprint(vcenter.ThumbprintSHA1) || c.KnownThumbprint(vcenter.ThumbprintSHA256) {
+				vcenter.Status = "good"
+			}
+		}
+		printCertificate("vcenter", c.URL().Hostname(), &vcenter, *now)
+	}
+
+	for _, hs := range hss {
+		info := &object.HostCertificateInfo{}
+		if hs.ConfigManager.CertificateManager != nil {
+			info, err = object.NewHostCertificateManager(c, *hs.ConfigManager.CertificateManager, hs.Self).CertificateInfo(ctx)
+			if err != nil {
+				// disconnected or not responding hosts must not hide the other rows
+				printCertificateError("host", hs.Name, err)
+				continue
+			}
+		}
+		// fall back to the certificate in the host configuration when the manager reports nothing
+		if (info.NotAfter == nil || info.ThumbprintSHA1 == "") && hs.Config != nil && len(hs.Config.Certificate) > 0 {
+			pem, err := new(object.HostCertificateInfo).FromPEM(hs.Config.Certificate)
+			if err != nil {
+				printCertificateError("host", hs.Name, err)
+				continue
+			}
+			if info.NotAfter == nil {
+				if info.Status != "" {
+					pem.Status = info.Status
+				}
+				info = pem
+			}
+			info.ThumbprintSHA1 = pem.ThumbprintSHA1
+		}
+		printCertificate("host", hs.Name, info, *now)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+	"strings"
+)
+
+func GetClusterPolicies(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"ClusterComputeResource"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var clusters []mo.ClusterComputeResource
+
+	err = v.RetrieveWithFilter(ctx, []string{"ClusterComputeResource"}, []string{"summary", "configurationEx", "recommendation"}, &clusters, property.Match{"self.value": clusterFlag})
+
+	if err != nil {
+		return err
+	}
+
+	// rules, groups and recommendations reference VMs and hosts by moref
+	var vmNames, hostNames map[string]string
+	if sectionFlag != "ha" && sectionFlag != "drs" {
+		vmNames, err = getEntityNames(ctx, c, "VirtualMachine")
+		if err != nil {
+			return err
+		}
+		hostNames, err = getEntityNames(ctx, c, "HostSystem")
+		if err != nil {
+			return err
+		}
+	}
+
+	switch sectionFlag {
+	case "ha":
+		fmt.Fprint(os.Stdout, "cluster;haEnabled;hostMonitoring;vmMonitoring;restartPriority;isolationResponse;admissionControlEnabled;admissionControlPolicy;failoverLevel;cpuFailoverPercent;memoryFailoverPercent;currentFailoverLevel;currentCpuFailoverPercent;currentMemoryFailoverPercent\n")
+	case "drs":
+		fmt.Fprint(os.Stdout, "cluster;drsEnabled;automationLevel;migrationThreshold;vmBehaviorOverrides;numRecommendations;drsScore\n")
+	case "rules":
+		fmt.Fprint(os.Stdout, "cluster;rule;type;enabled;mandatory;inCompliance;status;vms;vmGroup;hostGroup\n")
+	case "groups":
+		fmt.Fprint(os.Stdout, "cluster;group;type;members\n")
+	case "recommendations":
+		fmt.Fprint(os.Stdout, "cluster;key;type;rating;reason;reasonText;target;actions;time\n")
+	}
+
+	clusterFound := false
+	for _, cluster := range clusters {
+		clusterFound = true
+		config, ok := cluster.ConfigurationEx.(*types.ClusterConfigInfoEx)
+		if !ok {
+			continue
+		}
+
+		switch sectionFlag {
+		case "ha":
+			das := config.DasConfig
+			restartPriority, isolationResponse := "NA", "NA"
+			if das.DefaultVmSettings != nil {
+				restartPriority = das.DefaultVmSettings.RestartPriority
+				isolationResponse = das.DefaultVmSettings.IsolationResponse
+			}
+			policy, failoverLevel, cpuPercent, memoryPercent := admissionControlPolicy(das)
+			currentFailoverLevel, currentCpuPercent, currentMemoryPercent := admissionControlInfo(cluster.Summary)
+
+			fmt.Fprintf(os.Stdout, "%s;%v;%s;%s;%s;%s;%v;%s;%d;%d;%d;%s;%s;%s\n",
+				safeValue(cluster.Self.Value),
+				safeValue(das.Enabled != nil && *das.Enabled),
+				safeValue(das.HostMonitoring),
+				safeValue(das.VmMonitoring),
+				safeValue(restartPriority),
+				safeValue(isolationResponse),
+				safeValue(das.AdmissionControlEnabled != nil && *das.AdmissionControlEnabled),
+				safeValue(policy),
+				failoverLevel,
+				cpuPercent,
+				memoryPercent,
+				safeValue(currentFailoverLevel),
+				safeValue(currentCpuPercent),
+				safeValue(currentMemoryPercent),
+			)
+		case "drs":
+			drs := config.DrsConfig
+			drsScore := "NA"
+			if summary, ok := cluster.Summary.(*types.ClusterComputeResourceSummary); ok {
+				drsScore = fmt.Sprintf("%d", summary.DrsScore)
+			}
+			fmt.Fprintf(os.Stdout, "%s;%v;%s;%d;%v;%d;%s\n",
+				safeValue(cluster.Self.Value),
+				safeValue(drs.Enabled != nil && *drs.Enabled),
+				safeValue(drs.DefaultVmBehavior),
+				safeValue(drs.VmotionRate),
+				safeValue(drs.EnableVmBehaviorOverrides != nil && *drs.EnableVmBehaviorOverrides),
+				len(cluster.Recommendation),
+				safeValue(drsScore),
+			)
+		case "rules":
+			for _, r := range config.Rule {
+				rule := r.GetClusterRuleInfo()
+				ruleType, vms, vmGroup, hostGroup := "NA", "NA", "NA", "NA"
+				switch rr := r.(type) {
+				case *types.ClusterAffinityRuleSpec:
+					ruleType = "affinity"
+					vms = joinEntityNames(rr.Vm, vmNames)
+				case *types.ClusterAntiAffinityRuleSpec:
+					ruleType = "antiAffinity"
+					vms = joinEntityNames(rr.Vm, vmNames)
+				case *types.ClusterVmHostRuleInfo:
+					ruleType = "vmHostAffine"
+					hostGroup = rr.AffineHostGroupName
+					if rr.AntiAffineHostGroupName != "" {
+						ruleType = "vmHostAntiAffine"
+						hostGroup = rr.AntiAffineHostGroupName
+					}
+					vmGroup = rr.VmGroupName
+				case *types.ClusterDependencyRuleInfo:
+					ruleType = "dependency"
+					vmGroup = rr.VmGroup + ">" + rr.DependsOnVmGroup
+				}
+
+				inCompliance := "NA"
+				if rule.InCompliance != nil {
+					inCompliance = fmt.Sprintf("%t", *rule.InCompliance)
+				}
+
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%v;%v;%s;%s;%s;%s;%s\n",
+					safeValue(cluster.Self.Value),
+					safeValue(rule.Name),
+					safeValue(ruleType),
+					safeValue(rule.Enabled != nil && *rule.Enabled),
+					safeValue(rule.Mandatory != nil && *rule.Mandatory),
+					safeValue(inCompliance),
+					safeValue(rule.Status),
+					safeValue(vms),
+					safeValue(vmGroup),
+					safeValue(hostGroup),
+				)
+			}
+		case "groups":
+			for _, g := range config.Group {
+				groupType, members := "NA", "NA"
+				switch gg := g.(type) {
+				case *types.ClusterVmGroup:
+					groupType = "vm"
+					members = joinEntityNames(gg.Vm, vmNames)
+				case *types.ClusterHostGroup:
+					groupType = "host"
+					members = joinEntityNames(gg.Host, hostNames)
+				}
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%s\n",
+					safeValue(cluster.Self.Value),
+					safeValue(g.GetClusterGroupInfo().Name),
+					safeValue(groupType),
+					safeValue(members),
+				)
+			}
+		case "recommendations":
+			for _, recommendation := range cluster.Recommendation {
+				target := "NA"
+				if recommendation.Target != nil {
+					target = recommendation.Target.Value
+				}
+				var actions []string
+				for _, action := range recommendation.Action {
+					actions = append(actions, action.GetClusterAction().Type)
+				}
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%s;%s;%s;%s;%s\n",
+					safeValue(cluster.Self.Value),
+					safeValue(recommendation.Key),
+					safeValue(recommendation.Type),
+					safeValue(recommendation.Rating),
+					safeValue(recommendation.Reason),
+					safeValue(recommendation.ReasonText),
+					safeValue(target),
+					safeValue(strings.Join(actions, ",")),
+					safeValue(&recommendation.Time),
+				)
+			}
+		}
+	}
+	if !clusterFound {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Cluster not found.")
+		os.Exit(1)
+	}
+	return nil
+}
+
+// admissionControlPolicy returns the HA admission control policy name, failover level and reserved cpu/memory percentages
+func admissionControlPolicy(das types.ClusterDasConfigInfo) (string, int32, int32, int32) {
+	switch p := das.AdmissionControlPolicy.(type) {
+	case *types.ClusterFailoverLevelAdmissionControlPolicy:
+		return "slotPolicy", p.FailoverLevel, 0, 0
+	case *types.ClusterFailoverResourcesAdmissionControlPolicy:
+		return "clusterResourcePercentage", p.FailoverLevel, p.CpuFailoverResourcesPercent, p.MemoryFailoverResourcesPercent
+	case *types.ClusterFailoverHostAdmissionControlPolicy:
+		return "dedicatedFailoverHosts", p.FailoverLevel, 0, 0
+	default:
+		return "NA", das.FailoverLevel, 0, 0
+	}
+}
+
+// admissionControlInfo returns the current HA failover capacity of the cluster
+func admissionControlInfo(summary types.BaseComputeResourceSummary) (string, string, string) {
+	clusterSummary, ok := summary.(*types.ClusterComputeResourceSummary)
+	if !ok {
+		return "NA", "NA", "NA"
+	}
+	switch info := clusterSummary.AdmissionControlInfo.(type) {
+	case *types.ClusterFailoverResourcesAdmissionControlInfo:
+		return fmt.Sprintf("%d", clusterSummary.CurrentFailoverLevel),
+			fmt.Sprintf("%d", info.CurrentCpuFailoverResourcesPercent),
+			fmt.Sprintf("%d", info.CurrentMemoryFailoverResourcesPercent)
+	case *types.ClusterFailoverLevelAdmissionControlInfo:
+		return fmt.Sprintf("%d", info.CurrentFailoverLevel), "NA", "NA"
+	default:
+		return fmt.Sprintf("%d", clusterSummary.CurrentFailoverLevel), "NA", "NA"
+	}
+}
+
+func joinEntityNames(refs []types.ManagedObjectReference, names map[string]string) string {
+	var result []string
+	for _, ref := range refs {
+		if name, ok := names[ref.Value]; ok {
+			result = append(result, name)
+		} else {
+			result = append(result, ref.Value)
+		}
+	}
+	return strings.Join(result, ",")
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"gopkg.in/yaml.v3"
+	"os"
+	"sort"
+	"strings"
+)
+
+// baseline is the expected host configuration read from the compliance YAML file
+type baseline struct {
+	Build            string                     `yaml:"build"`
+	NtpServers       []string                   `yaml:"ntpServers"`
+	DnsServers       []string                   `yaml:"dnsServers"`
+	Syslog           string                     `yaml:"syslog"`
+	AdvancedSettings map[string]string          `yaml:"advancedSettings"`
+	Services         map[string]serviceBaseline `yaml:"services"`
+}
+
+type serviceBaseline struct {
+	Running *bool  `yaml:"running"`
+	Policy  string `yaml:"policy"`
+}
+
+// hostSettings holds the host configuration compared against the baseline
+type hostSettings struct {
+	name       string
+	build      string
+	ntpServers []string
+	dnsServers []string
+	services   map[string]types.HostService
+	options    map[string]string
+}
+
+func loadBaseline(file string) (*baseline, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var b baseline
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %s", file, err)
+	}
+	return &b, nil
+}
+
+// getHostSettings reads the host configuration, services and advanced options are only queried when
+// requested since the option manager returns every option of the host
+func getHostSettings(ctx context.Context, c *vim25.Client, hs mo.HostSystem, withServices bool, withOptions bool) (*hostSettings, error) {
+	settings := &hostSettings{
+		name:     hs.Name,
+		services: make(map[string]types.HostService),
+		options:  make(map[string]string),
+	}
+	if hs.Config == nil {
+		return settings, nil
+	}
+
+	settings.build = hs.Config.Product.Build
+	if hs.Config.DateTimeInfo != nil && hs.Config.DateTimeInfo.NtpConfig != nil {
+		settings.ntpServers = hs.Config.DateTimeInfo.NtpConfig.Server
+	}
+	if hs.Config.Network != nil && hs.Config.Network.DnsConfig != nil {
+		settings.dnsServers = hs.Config.Network.DnsConfig.GetHostDnsConfig().Address
+	}
+
+	// the service system reports the live service state, config.service is used when the host has none
+	if withServices {
+		var services []types.HostService
+		if hs.Config.Service != nil {
+			services = hs.Config.Service.Service
+		}
+		if hs.ConfigManager.ServiceSystem != nil {
+			values, err := object.NewHostServiceSystem(c, *hs.ConfigManager.ServiceSystem).Service(ctx)
+			if err != nil {
+				return nil, err
+			}
+			services = values
+		}
+		for _, service := range services {
+			settings.services[service.Key] = service
+		}
+	}
+
+	// the option manager returns the current values, config.option is used when the host has none
+	if withOptions {
+		options := hs.Config.Option
+		if hs.ConfigManager.AdvancedOption != nil {
+			values, err := object.NewOptionManager(c, *hs.ConfigManager.AdvancedOption).Query(ctx, "")
+			if err != nil {
+				return nil, err
+			}
+			options = values
+		}
+		for _, option := range options {
+			value := option.GetOptionValue()
+			settings.options[value.Key] = fmt.Sprintf("%v", value.Value)
+		}
+	}
+	return settings, nil
+}
+
+// sortedList joins a list in a stable order so lists compare regardless of ordering
+func sortedList(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+func printComplianceRule(host string, rule string, expected string, actual string) {
+	result := "fail"
+	if expected == actual {
+		result = "pass"
+	}
+	fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s\n",
+		safeValue(host),
+		safeValue(rule),
+		safeValue(expected),
+		safeValue(actual),
+		result,
+	)
+}
+
+func GetHostsCompliance(ctx context.Context, c *vim25.Client) error {
+	b, err := loadBaseline(baselineFlag)
+	if err != nil {
+		return err
+	}
+
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"HostSystem"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var hss []mo.HostSystem
+
+	err = v.RetrieveWithFilter(ctx, []string{"HostSystem"}, []string{"name", "config.product", "config.dateTimeInfo", "config.network.dnsConfig", "config.service", "config.option", "configManager.advancedOption", "configManager.serviceSystem"}, &hss, property.Match{"name": hostFlag})
+	if err != nil {
+		return err
+	}
+
+	var advancedKeys, serviceKeys []string
+	for key := range b.AdvancedSettings {
+		advancedKeys = append(advancedKeys, key)
+	}
+	for key := range b.Services {
+		serviceKeys = append(serviceKeys, key)
+	}
+	sort.Strings(advancedKeys)
+	sort.Strings(serviceKeys)
+
+	fmt.Fprint(os.Stdout, "host;rule;expected;actual;result\n")
+
+	withServices := len(b.Services) > 0
+	withOptions := b.Syslog != "" || len(b.AdvancedSettings) > 0
+
+	hostFound := false
+	for _, hs := range hss {
+		hostFound = true
+		settings, err := getHostSettings(ctx, c, hs, withServices, withOptions)
+		if err != nil {
+			return err
+		}
+
+		// only the rules present in the baseline are checked
+		if b.Build != "" {
+			printComplianceRule(settings.name, "build", b.Build, settings.build)
+		}
+		if b.NtpServers != nil {
+			printComplianceRule(settings.name, "ntpServers", sortedList(b.NtpServers), sortedList(settings.ntpServers))
+		}
+		// DNS servers are queried in order, so the order is part of the rule
+		if b.DnsServers != nil {
+			printComplianceRule(settings.name, "dnsServers", strings.Join(b.DnsServers, ","), strings.Join(settings.dnsServers, ","))
+		}
+		if b.Syslog != "" {
+			actual, ok := settings.options["Syslog.global.logHost"]
+			if !ok {
+				actual = "NA"
+			}
+			printComplianceRule(settings.name, "syslog", b.Syslog, actual)
+		}
+		for _, key := range advancedKeys {
+			actual, ok := settings.options[key]
+			if !ok {
+				actual = "NA"
+			}
+			printComplianceRule(settings.name, "advanced:"+key, b.AdvancedSettings[key], actual)
+		}
+		for _, key := range serviceKeys {
+			expected := b.Services[key]
+			service, ok := settings.services[key]
+			if expected.Running != nil {
+				actual := "NA"
+				if ok {
+					actual = fmt.Sprintf("%t", service.Running)
+				}
+				printComplianceRule(settings.name, "service:"+key+":running", fmt.Sprintf("%t", *expected.Running), actual)
+			}
+			if expected.Policy != "" {
+				actual := "NA"
+				if ok {
+					actual = service.Policy
+				}
+				printComplianceRule(settings.name, "service:"+key+":policy", expected.Policy, actual)
+			}
+		}
+	}
+	if !hostFound {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Host not found.")
+		os.Exit(1)
+	}
+	return nil
+}
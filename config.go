@@ -7,6 +7,7 @@ import (
 	"github.com/vmware/govmomi/view"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
 	"os"
 	"strings"
 )
@@ -187,7 +188,7 @@ func GetResourcePoolConfig(ctx context.Context, c *vim25.Client) error {
 	}
 	var vmNames strings.Builder
 
-	fmt.Fprint(os.Stdout, "name;vmNames;cpuReservation;cpuExpandableReservation;cpuLimit;memoryReservation;memoryExpandableReservation;memoryLimit\n")
+	fmt.Fprint(os.Stdout, "name;parent;vmNames;cpuReservation;cpuExpandableReservation;cpuLimit;cpuShares;cpuSharesLevel;memoryReservation;memoryExpandableReservation;memoryLimit;memoryShares;memorySharesLevel\n")
 
 	resourcePoolFound := false
 
@@ -201,16 +202,27 @@ func GetResourcePoolConfig(ctx context.Context, c *vim25.Client) error {
 			vmNames.WriteString(vmName.Value)
 		}
 
-		fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%t;%d;%d;%t;%d\n",
+		parent := "NA"
+		if rp.Parent != nil {
+			parent = rp.Parent.Value
+		}
+		cpu := allocationValues(rp.Config.CpuAllocation)
+		memory := allocationValues(rp.Config.MemoryAllocation)
+
+		fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%s;%s;%s;%s;%s;%s;%s\n",
 			safeValue(rp.Self.Value),
-			safeValue(rp.Parent.Value),
+			safeValue(parent),
 			safeValue(vmNames.String()),
-			safeValue(*rp.Config.CpuAllocation.Reservation),
-			safeValue(*rp.Config.CpuAllocation.ExpandableReservation),
-			safeValue(*rp.Config.CpuAllocation.Limit),
-			safeValue(*rp.Config.MemoryAllocation.Reservation),
-			safeValue(*rp.Config.MemoryAllocation.ExpandableReservation),
-			safeValue(*rp.Config.MemoryAllocation.Limit),
+			cpu.reservation,
+			cpu.expandable,
+			cpu.limit,
+			cpu.shares,
+			cpu.level,
+			memory.reservation,
+			memory.expandable,
+			memory.limit,
+			memory.shares,
+			memory.level,
 		)
 
 		//
@@ -224,75 +236,266 @@ func GetResourcePoolConfig(ctx context.Context, c *vim25.Client) error {
 }
 
 func GetDatastoreConfig(ctx context.Context, c *vim25.Client) error {
-
-	var err error
-	var hostNames []string
-	if hostFlag != "*" {
-		hostNames, err = getHostNames(ctx, c, mountedOnFlag)
+	hostNames, err := getEntityNames(ctx, c, "HostSystem")
+	if err != nil {
+		return err
 	}
 
+	dss, err := getDatastores(ctx, c, []string{"summary", "host", "info", "vm"})
 	if err != nil {
+		return err
+	}
+	if len(dss) == 0 {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Datastore not found.")
+		os.Exit(1)
+	}
+
+	fmt.Fprint(os.Stdout, "name;internalName;type;capacity;maxFileSize;maxMemoryFileSize;MaxVirtualDiskCapacity;mountedOnHosts;mountedOnVms;mounts\n")
 
+	for _, ds := range dss {
+		_, internalHostValues, _, mounts := datastoreMounts(ds, hostNames)
+
+		var vmNames []string
+		for _, vm := range ds.Vm {
+			vmNames = append(vmNames, vm.Value)
+		}
+
+		fmt.Fprintf(os.Stdout, "%s;%s;%s;%v;%d;%d;%d;%s;%s;%s\n",
+			safeValue(ds.Summary.Name),
+			safeValue(ds.Summary.Datastore.Value),
+			safeValue(ds.Summary.Type),
+			safeValue(ds.Summary.Capacity),
+			safeValue(ds.Info.GetDatastoreInfo().MaxFileSize),
+			safeValue(ds.Info.GetDatastoreInfo().MaxMemoryFileSize),
+			safeValue(ds.Info.GetDatastoreInfo().MaxVirtualDiskCapacity),
+			safeValue(strings.Join(internalHostValues, ",")),
+			safeValue(strings.Join(vmNames, ",")),
+			safeValue(strings.Join(mounts, ",")),
+		)
+	}
+	return nil
+}
+
+func GetDatastoreClusterConfig(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"StoragePod"}, true)
+	if err != nil {
 		return err
+	}
+	defer v.Destroy(ctx)
+	var pods []mo.StoragePod
+
+	err = v.RetrieveWithFilter(ctx, []string{"StoragePod"}, []string{"name", "summary", "childEntity", "podStorageDrsEntry"}, &pods, property.Match{"name": datastoreClusterFlag})
+	if err != nil || len(pods) == 0 {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Datastore cluster not found.")
+		os.Exit(1)
+	}
+
+	fmt.Fprint(os.Stdout, "name;internalName;capacity;freeSpace;sdrsEnabled;defaultVmBehavior;ioLoadBalanceEnabled;loadBalanceInterval;spaceUtilizationThreshold;datastores\n")
+	for _, pod := range pods {
+		var dss []mo.Datastore
+		if len(pod.ChildEntity) > 0 {
+			err = property.DefaultCollector(c).Retrieve(ctx, pod.ChildEntity, []string{"name"}, &dss)
+			if err != nil {
+				return err
+			}
+		}
+		var dsNames []string
+		for _, ds := range dss {
+			dsNames = append(dsNames, ds.Name)
+		}
+
+		var capacity, freeSpace int64
+		if pod.Summary != nil {
+			capacity, freeSpace = pod.Summary.Capacity, pod.Summary.FreeSpace
+		}
+
+		// storage DRS settings are not returned when access to the cluster is restricted
+		sdrsEnabled, defaultVmBehavior, ioLoadBalanceEnabled, loadBalanceInterval, spaceThreshold := "NA", "NA", "NA", "NA", "NA"
+		if pod.PodStorageDrsEntry != nil {
+			config := pod.PodStorageDrsEntry.StorageDrsConfig.PodConfig
+			sdrsEnabled = fmt.Sprintf("%t", config.Enabled)
+			defaultVmBehavior = config.DefaultVmBehavior
+			ioLoadBalanceEnabled = fmt.Sprintf("%t", config.IoLoadBalanceEnabled)
+			loadBalanceInterval = fmt.Sprintf("%d", config.LoadBalanceInterval)
+			if config.SpaceLoadBalanceConfig != nil {
+				spaceThreshold = fmt.Sprintf("%d", config.SpaceLoadBalanceConfig.SpaceUtilizationThreshold)
+			}
+		}
+
+		fmt.Fprintf(os.Stdout, "%s;%s;%d;%d;%s;%s;%s;%s;%s;%s\n",
+			safeValue(pod.Name),
+			safeValue(pod.Self.Value),
+			capacity,
+			freeSpace,
+			safeValue(sdrsEnabled),
+			safeValue(defaultVmBehavior),
+			safeValue(ioLoadBalanceEnabled),
+			safeValue(loadBalanceInterval),
+			safeValue(spaceThreshold),
+			safeValue(strings.Join(dsNames, ",")),
+		)
+	}
+	return nil
+}
 
+func GetDVSConfig(ctx context.Context, c *vim25.Client) error {
+	hostNames, err := getEntityNames(ctx, c, "HostSystem")
+	if err != nil {
+		return err
 	}
 
 	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"DistributedVirtualSwitch"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var dvss []mo.DistributedVirtualSwitch
+
+	err = v.RetrieveWithFilter(ctx, []string{"DistributedVirtualSwitch"}, []string{"name", "summary", "config"}, &dvss, property.Match{"name": dvsFlag})
 
-	vDatastore, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"Datastore"}, true)
 	if err != nil {
 		return err
 	}
-	defer vDatastore.Destroy(ctx)
 
-	fmt.Fprint(os.Stdout, "name;internalName;capacity;maxFileSize;maxMemoryFileSize;MaxVirtualDiskCapacity;mountedOnHosts;mountedOnVms\n")
+	dvsFound := false
+
+	fmt.Fprint(os.Stdout, "name;internalName;uuid;version;numPorts;maxPorts;hosts;uplinkPortNames;uplinkMapping;portgroups\n")
+	for _, dvs := range dvss {
+		// config is not returned when access to the switch is restricted
+		if dvs.Config == nil {
+			fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%s;%s;%s;%s\n",
+				safeValue(dvs.Name),
+				safeValue(dvs.Self.Value),
+				"NA", "NA", "NA", "NA", "NA", "NA", "NA",
+				safeValue(strings.Join(dvs.Summary.PortgroupName, ",")),
+			)
+			dvsFound = true
+			continue
+		}
+		config := dvs.Config.GetDVSConfigInfo()
 
-	// Destination slice to hold the result
-	var dss []mo.Datastore
+		var hosts, uplinkMapping []string
+		for _, member := range config.Host {
+			if member.Config.Host == nil {
+				continue
+			}
+			hostName := hostNames[member.Config.Host.Value]
+			hosts = append(hosts, hostName)
+
+			// physical NICs backing the uplinks of each member host
+			var pnics []string
+			if backing, ok := member.Config.Backing.(*types.DistributedVirtualSwitchHostMemberPnicBacking); ok {
+				for _, spec := range backing.PnicSpec {
+					pnics = append(pnics, spec.PnicDevice)
+				}
+			}
+			uplinkMapping = append(uplinkMapping, hostName+"="+strings.Join(pnics, " "))
+		}
 
-	// Retrieve datastores the match the filter
-	err = vDatastore.RetrieveWithFilter(ctx, []string{"Datastore"}, []string{"summary", "host", "info", "vm"}, &dss, property.Match{"name": datastoreFlag})
+		var uplinkPortNames []string
+		if policy, ok := config.UplinkPortPolicy.(*types.DVSNameArrayUplinkPortPolicy); ok {
+			uplinkPortNames = policy.UplinkPortName
+		}
 
+		fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%d;%d;%s;%s;%s;%s\n",
+			safeValue(dvs.Name),
+			safeValue(dvs.Self.Value),
+			safeValue(config.Uuid),
+			safeValue(config.ProductInfo.Version),
+			safeValue(config.NumPorts),
+			safeValue(config.MaxPorts),
+			safeValue(strings.Join(hosts, ",")),
+			safeValue(strings.Join(uplinkPortNames, ",")),
+			safeValue(strings.Join(uplinkMapping, ",")),
+			safeValue(strings.Join(dvs.Summary.PortgroupName, ",")),
+		)
+
+		dvsFound = true
+	}
+	if !dvsFound {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "DVS not found.")
+		os.Exit(1)
+	}
+	return nil
+}
+
+func GetPortgroupConfig(ctx context.Context, c *vim25.Client) error {
+	dvsNames, err := getEntityNames(ctx, c, "DistributedVirtualSwitch")
 	if err != nil {
 		return err
 	}
-	datastoreFound := false
-	// Iterate over the filtered datastores
-	for _, ds := range dss {
-		var internalHostValues []string
-		for _, host := range ds.Host {
-			internalHostValues = append(internalHostValues, host.Key.Value)
-		}
 
-		// same for vms
-		var vmNames []string
-		for _, vm := range ds.Vm {
-			vmNames = append(vmNames, vm.Value)
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"DistributedVirtualPortgroup"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var pgs []mo.DistributedVirtualPortgroup
+
+	err = v.RetrieveWithFilter(ctx, []string{"DistributedVirtualPortgroup"}, []string{"name", "key", "config"}, &pgs, property.Match{"name": portgroupFlag})
+
+	if err != nil {
+		return err
+	}
+
+	portgroupFound := false
+
+	fmt.Fprint(os.Stdout, "name;internalName;key;dvs;type;numPorts;vlanType;vlan;activeUplinks;standbyUplinks\n")
+	for _, pg := range pgs {
+		dvsName := "NA"
+		if pg.Config.DistributedVirtualSwitch != nil {
+			dvsName = dvsNames[pg.Config.DistributedVirtualSwitch.Value]
 		}
 
-		if hostFlag != "*" {
-			if !containsAny(hostNames, internalHostValues) {
-				continue
+		vlanType, vlan := "NA", "NA"
+		var activeUplinks, standbyUplinks []string
+		if setting, ok := pg.Config.DefaultPortConfig.(*types.VMwareDVSPortSetting); ok {
+			vlanType, vlan = portgroupVlan(setting.Vlan)
+			if setting.UplinkTeamingPolicy != nil && setting.UplinkTeamingPolicy.UplinkPortOrder != nil {
+				activeUplinks = setting.UplinkTeamingPolicy.UplinkPortOrder.ActiveUplinkPort
+				standbyUplinks = setting.UplinkTeamingPolicy.UplinkPortOrder.StandbyUplinkPort
 			}
 		}
 
-		fmt.Fprintf(os.Stdout, "%s;%s;%s;%v;%d;%d;%d;%s;%s\n",
-			safeValue(ds.Summary.Name),
-			safeValue(ds.Summary.Datastore.Value),
-			safeValue(ds.Summary.Type),
-			safeValue(ds.Summary.Capacity),
-			safeValue(ds.Info.GetDatastoreInfo().MaxFileSize),
-			safeValue(ds.Info.GetDatastoreInfo().MaxMemoryFileSize),
-			safeValue(ds.Info.GetDatastoreInfo().MaxVirtualDiskCapacity),
-			safeValue(strings.Join(internalHostValues, ",")),
-			safeValue(strings.Join(vmNames, ",")),
+		fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%d;%s;%s;%s;%s\n",
+			safeValue(pg.Name),
+			safeValue(pg.Self.Value),
+			safeValue(pg.Key),
+			safeValue(dvsName),
+			safeValue(pg.Config.Type),
+			safeValue(pg.Config.NumPorts),
+			safeValue(vlanType),
+			safeValue(vlan),
+			safeValue(strings.Join(activeUplinks, ",")),
+			safeValue(strings.Join(standbyUplinks, ",")),
 		)
 
-		datastoreFound = true
+		portgroupFound = true
 	}
-	if !datastoreFound {
-		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Datastore not found.")
+	if !portgroupFound {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Portgroup not found.")
 		os.Exit(1)
 	}
 	return nil
 }
+
+// portgroupVlan returns the VLAN type (vlan, trunk or pvlan) and the VLAN ID or trunk ranges
+func portgroupVlan(spec types.BaseVmwareDistributedVirtualSwitchVlanSpec) (string, string) {
+	switch s := spec.(type) {
+	case *types.VmwareDistributedVirtualSwitchVlanIdSpec:
+		return "vlan", fmt.Sprintf("%d", s.VlanId)
+	case *types.VmwareDistributedVirtualSwitchTrunkVlanSpec:
+		var ranges []string
+		for _, r := range s.VlanId {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", r.Start, r.End))
+		}
+		return "trunk", strings.Join(ranges, ",")
+	case *types.VmwareDistributedVirtualSwitchPvlanSpec:
+		return "pvlan", fmt.Sprintf("%d", s.PvlanId)
+	default:
+		return "NA", "NA"
+	}
+}
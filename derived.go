@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"sort"
+	"strings"
+)
+
+// derivedMetric is a metric computed per sample from raw counters of the same entity and instance
+type derivedMetric struct {
+	inputs []string
+	unit   string
+	// entityTypes are the entity types providing the inputs and the properties the metric needs
+	entityTypes []string
+	// unitKey is the vSphere unit key used by --normalize, empty when the value is already canonical
+	unitKey string
+	// compute receives the input values in order and the sample context
+	compute func(values []float64, sample derivedSample) (float64, bool)
+}
+
+// derivedSample is the context of a sample needed by some derived metrics
+type derivedSample struct {
+	// interval is the sample interval in seconds
+	interval float64
+	// linkSpeedMb is the link speed in Mbps of the instance, 0 when unknown
+	linkSpeedMb float64
+	// numCpu is the number of CPUs summed in the instance, 1 for a per CPU instance
+	numCpu float64
+}
+
+// derivedEntity holds the entity properties used by derived metrics
+type derivedEntity struct {
+	// linkSpeeds is the link speed in Mbps per instance, the aggregate instance gets the sum of the nics
+	linkSpeeds map[string]float64
+	// numCpu is the number of vCPUs of a VM or CPU threads of a host
+	numCpu int
+}
+
+// summationPercent converts a millisecond summation counter to the percentage of the sample interval.
+// Summations of the aggregate instance add up every CPU, so they are averaged per CPU to stay within 100%.
+func summationPercent(values []float64, sample derivedSample) (float64, bool) {
+	if sample.interval <= 0 || sample.numCpu <= 0 {
+		return 0, false
+	}
+	return values[0] / sample.numCpu / (sample.interval * 1000) * 100, true
+}
+
+// ratioPercent returns the first input as a percentage of the second one
+func ratioPercent(values []float64, _ derivedSample) (float64, bool) {
+	if values[1] <= 0 {
+		return 0, false
+	}
+	return values[0] / values[1] * 100, true
+}
+
+// sumValues adds the inputs, used for read and write latency totals
+func sumValues(values []float64, _ derivedSample) (float64, bool) {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total, true
+}
+
+// derivedMetrics can be requested by name in --metrics alongside raw counters.
+// Ready and co-stop of the aggregate instance are summed across vCPUs. Network utilization
+// needs the physical nic link speed, so it is only available for hosts.
+var derivedMetrics = map[string]derivedMetric{
+	"cpu.ready.percent": {
+		entityTypes: []string{"VirtualMachine", "HostSystem"},
+		inputs:      []string{"cpu.ready.summation"},
+		unit:        "%",
+		compute:     summationPercent,
+	},
+	"cpu.costop.percent": {
+		entityTypes: []string{"VirtualMachine", "HostSystem"},
+		inputs:      []string{"cpu.costop.summation"},
+		unit:        "%",
+		compute:     summationPercent,
+	},
+	"mem.balloon.percent": {
+		entityTypes: []string{"VirtualMachine", "HostSystem", "ClusterComputeResource", "ResourcePool", "VirtualApp"},
+		inputs:      []string{"mem.vmmemctl.average", "mem.granted.average"},
+		unit:        "%",
+		compute:     ratioPercent,
+	},
+	"mem.active.percent": {
+		entityTypes: []string{"VirtualMachine", "HostSystem", "ClusterComputeResource", "ResourcePool", "VirtualApp"},
+		inputs:      []string{"mem.active.average", "mem.granted.average"},
+		unit:        "%",
+		compute:     ratioPercent,
+	},
+	"disk.latency.total": {
+		entityTypes: []string{"HostSystem"},
+		inputs:      []string{"disk.totalReadLatency.average", "disk.totalWriteLatency.average"},
+		unit:        "ms",
+		unitKey:     "millisecond",
+		compute:     sumValues,
+	},
+	"virtualDisk.latency.total": {
+		entityTypes: []string{"VirtualMachine"},
+		inputs:      []string{"virtualDisk.totalReadLatency.average", "virtualDisk.totalWriteLatency.average"},
+		unit:        "ms",
+		unitKey:     "millisecond",
+		compute:     sumValues,
+	},
+	"net.utilization.percent": {
+		entityTypes: []string{"HostSystem"},
+		inputs:      []string{"net.usage.average"},
+		unit:        "%",
+		// usage is in KBps with 1 KB = 1024 bytes, link speed in Mbps with 1 Mb = 1000000 bits
+		compute: func(values []float64, sample derivedSample) (float64, bool) {
+			if sample.linkSpeedMb <= 0 {
+				return 0, false
+			}
+			return values[0] * 8 * 1024 / 1000 / 1000 / sample.linkSpeedMb * 100, true
+		},
+	},
+}
+
+// statsRow is the series of values printed for one entity, metric and instance
+type statsRow struct {
+	instance string
+	values   []float64
+	unit     string
+	unitKey  string
+}
+
+// checkDerivedEntityType rejects derived metrics that cannot be computed for the queried entity type
+func checkDerivedEntityType(selectors []metricSelector, entityToQuery string) error {
+	for _, selector := range selectors {
+		derived, ok := derivedMetrics[selector.name]
+		if ok && !contains(derived.entityTypes, entityToQuery) {
+			return fmt.Errorf("Metric '%s' is not available for %s, supported entities: %s", selector.name, entityToQuery, strings.Join(derived.entityTypes, ","))
+		}
+	}
+	return nil
+}
+
+// metricInputs returns the counters to query for a metric, the inputs of a derived metric or the metric itself
+func metricInputs(name string) []string {
+	if derived, ok := derivedMetrics[name]; ok {
+		return derived.inputs
+	}
+	return []string{name}
+}
+
+// derivedRows computes a derived metric sample by sample for every instance where all inputs were returned.
+// Samples where an input is unavailable (-1) or the value cannot be computed are skipped.
+func derivedRows(derived derivedMetric, selector metricSelector, metric performance.EntityMetric, entity derivedEntity) []statsRow {
+	inputsByInstance := make(map[string]map[string][]int64)
+	for _, s := range metric.Value {
+		if !selector.matchesInstance(s.Instance) {
+			continue
+		}
+		if inputsByInstance[s.Instance] == nil {
+			inputsByInstance[s.Instance] = make(map[string][]int64)
+		}
+		inputsByInstance[s.Instance][s.Name] = s.Value
+	}
+
+	var rows []statsRow
+	for instance, inputs := range inputsByInstance {
+		series := make([][]int64, len(derived.inputs))
+		complete := true
+		for i, input := range derived.inputs {
+			values, ok := inputs[input]
+			if !ok {
+				complete = false
+				break
+			}
+			series[i] = values
+		}
+		if !complete {
+			continue
+		}
+
+		sample := derivedSample{linkSpeedMb: entity.linkSpeeds[instance], numCpu: 1}
+		if instance == "" {
+			sample.numCpu = float64(entity.numCpu)
+		}
+
+		var values []float64
+		args := make([]float64, len(derived.inputs))
+		for j, info := range metric.SampleInfo {
+			available := true
+			for i := range series {
+				if j >= len(series[i]) || series[i][j] < 0 {
+					available = false
+					break
+				}
+				args[i] = float64(series[i][j])
+			}
+			if !available {
+				continue
+			}
+			sample.interval = float64(info.Interval)
+			if value, ok := derived.compute(args, sample); ok {
+				values = append(values, value)
+			}
+		}
+		if len(values) > 0 {
+			rows = append(rows, statsRow{instance: instance, values: values, unit: derived.unit, unitKey: derived.unitKey})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].instance < rows[j].instance })
+	return rows
+}
+
+// getDerivedEntities returns the CPU count of the selected hosts and VMs and the physical nic
+// link speeds of the selected hosts
+func getDerivedEntities(ctx context.Context, c *vim25.Client, entityToQuery string, refs []types.ManagedObjectReference) (map[string]derivedEntity, error) {
+	entities := make(map[string]derivedEntity)
+	pc := property.DefaultCollector(c)
+	switch entityToQuery {
+	case "HostSystem":
+		var hss []mo.HostSystem
+		err := pc.Retrieve(ctx, refs, []string{"config.network.pnic", "summary.hardware"}, &hss)
+		if err != nil {
+			return nil, err
+		}
+		for _, hs := range hss {
+			entity := derivedEntity{linkSpeeds: make(map[string]float64)}
+			if hs.Summary.Hardware != nil {
+				entity.numCpu = int(hs.Summary.Hardware.NumCpuThreads)
+			}
+			if hs.Config != nil && hs.Config.Network != nil {
+				for _, pnic := range hs.Config.Network.Pnic {
+					if pnic.LinkSpeed == nil {
+						continue
+					}
+					entity.linkSpeeds[pnic.Device] = float64(pnic.LinkSpeed.SpeedMb)
+					entity.linkSpeeds[""] += float64(pnic.LinkSpeed.SpeedMb)
+				}
+			}
+			entities[hs.Self.Value] = entity
+		}
+	case "VirtualMachine":
+		var vms []mo.VirtualMachine
+		err := pc.Retrieve(ctx, refs, []string{"summary.config.numCpu"}, &vms)
+		if err != nil {
+			return nil, err
+		}
+		for _, vm := range vms {
+			entities[vm.Self.Value] = derivedEntity{numCpu: int(vm.Summary.Config.NumCpu)}
+		}
+	}
+	return entities, nil
+}
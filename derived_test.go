@@ -0,0 +1,164 @@
+package main
+
+import (
+	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/vim25/types"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDerivedMetricFormulas(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric string
+		values []float64
+		sample derivedSample
+		want   float64
+		wantOk bool
+	}{
+		{
+			name:   "ready of one vCPU",
+			metric: "cpu.ready.percent",
+			values: []float64{2000},
+			sample: derivedSample{interval: 20, numCpu: 1},
+			want:   10,
+			wantOk: true,
+		},
+		{
+			name:   "ready summed across four vCPUs",
+			metric: "cpu.ready.percent",
+			values: []float64{8000},
+			sample: derivedSample{interval: 20, numCpu: 4},
+			want:   10,
+			wantOk: true,
+		},
+		{
+			name:   "co-stop of a 300 second sample",
+			metric: "cpu.costop.percent",
+			values: []float64{3000},
+			sample: derivedSample{interval: 300, numCpu: 1},
+			want:   1,
+			wantOk: true,
+		},
+		{
+			name:   "ready without interval",
+			metric: "cpu.ready.percent",
+			values: []float64{2000},
+			sample: derivedSample{numCpu: 1},
+			wantOk: false,
+		},
+		{
+			name:   "ready without CPU count",
+			metric: "cpu.ready.percent",
+			values: []float64{2000},
+			sample: derivedSample{interval: 20},
+			wantOk: false,
+		},
+		{
+			name:   "balloon of granted memory",
+			metric: "mem.balloon.percent",
+			values: []float64{1024, 4096},
+			want:   25,
+			wantOk: true,
+		},
+		{
+			name:   "active without granted memory",
+			metric: "mem.active.percent",
+			values: []float64{1024, 0},
+			wantOk: false,
+		},
+		{
+			name:   "read and write latency",
+			metric: "disk.latency.total",
+			values: []float64{3, 4},
+			want:   7,
+			wantOk: true,
+		},
+		{
+			// 1 Gbps is 1e9 / 8 / 1024 KBps
+			name:   "saturated gigabit link",
+			metric: "net.utilization.percent",
+			values: []float64{1e9 / 8 / 1024},
+			sample: derivedSample{linkSpeedMb: 1000},
+			want:   100,
+			wantOk: true,
+		},
+		{
+			name:   "10 MBps on a 10 Gbps link",
+			metric: "net.utilization.percent",
+			values: []float64{10 * 1024},
+			sample: derivedSample{linkSpeedMb: 10000},
+			want:   0.8388608,
+			wantOk: true,
+		},
+		{
+			name:   "unknown link speed",
+			metric: "net.utilization.percent",
+			values: []float64{1024},
+			wantOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := derivedMetrics[tt.metric].compute(tt.values, tt.sample)
+			if ok != tt.wantOk {
+				t.Fatalf("%s ok = %t, want %t", tt.metric, ok, tt.wantOk)
+			}
+			if ok && math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("%s = %v, want %v", tt.metric, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDerivedRows(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	metric := performance.EntityMetric{
+		Entity: types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"},
+		SampleInfo: []types.PerfSampleInfo{
+			{Timestamp: t0, Interval: 20},
+			{Timestamp: t0.Add(20 * time.Second), Interval: 20},
+		},
+		Value: []performance.MetricSeries{
+			{Name: "cpu.ready.summation", Instance: "", Value: []int64{4000, -1}},
+			{Name: "cpu.ready.summation", Instance: "0", Value: []int64{1000, 2000}},
+		},
+	}
+	selector := metricSelector{name: "cpu.ready.percent", instances: []string{"*"}}
+
+	rows := derivedRows(derivedMetrics["cpu.ready.percent"], selector, metric, derivedEntity{numCpu: 2})
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	// the aggregate is averaged over both vCPUs and the unavailable sample is skipped
+	if rows[0].instance != "" || len(rows[0].values) != 1 || rows[0].values[0] != 10 {
+		t.Errorf("aggregate row = %+v, want one 10%% sample", rows[0])
+	}
+	if rows[1].instance != "0" || len(rows[1].values) != 2 || rows[1].values[0] != 5 || rows[1].values[1] != 10 {
+		t.Errorf("vCPU row = %+v, want 5%% and 10%%", rows[1])
+	}
+}
+
+func TestCheckDerivedEntityType(t *testing.T) {
+	tests := []struct {
+		metric  string
+		entity  string
+		wantErr bool
+	}{
+		{metric: "cpu.ready.percent", entity: "VirtualMachine"},
+		{metric: "cpu.ready.percent", entity: "ClusterComputeResource", wantErr: true},
+		{metric: "mem.active.percent", entity: "ResourcePool"},
+		{metric: "net.utilization.percent", entity: "HostSystem"},
+		{metric: "net.utilization.percent", entity: "VirtualMachine", wantErr: true},
+		{metric: "cpu.usage.average", entity: "Datastore"},
+	}
+
+	for _, tt := range tests {
+		err := checkDerivedEntityType([]metricSelector{{name: tt.metric}}, tt.entity)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkDerivedEntityType(%s, %s) error = %v, wantErr %t", tt.metric, tt.entity, err, tt.wantErr)
+		}
+	}
+}
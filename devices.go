@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+	"strings"
+)
+
+func GetVMDevices(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"VirtualMachine"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var vms []mo.VirtualMachine
+
+	err = v.RetrieveWithFilter(ctx, []string{"VirtualMachine"}, []string{"summary", "config.hardware.device"}, &vms, property.Match{"name": vmFlag})
+
+	if err != nil {
+		return err
+	}
+
+	// distributed portgroups are referenced by key from the NIC backing
+	var portgroupNames map[string]string
+	if sectionFlag == "nics" {
+		portgroupNames, err = getPortgroupNames(ctx, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch sectionFlag {
+	case "nics":
+		fmt.Fprint(os.Stdout, "name;internalName;label;key;type;macAddress;addressType;portgroup;connected;startConnected\n")
+	case "cdroms":
+		fmt.Fprint(os.Stdout, "name;internalName;label;key;controller;unitNumber;backingType;backing;connected;startConnected\n")
+	case "controllers":
+		fmt.Fprint(os.Stdout, "name;internalName;label;key;type;busNumber;sharedBus;numDevices\n")
+	default:
+		fmt.Fprint(os.Stdout, "name;internalName;label;key;capacity;provisioning;datastore;fileName;controller;unitNumber;diskMode\n")
+	}
+
+	vmFound := false
+	for _, vm := range vms {
+		vmFound = true
+		if vm.Config == nil {
+			continue
+		}
+		devices := object.VirtualDeviceList(vm.Config.Hardware.Device)
+
+		for _, device := range devices {
+			d := device.GetVirtualDevice()
+
+			switch sectionFlag {
+			case "nics":
+				nic, ok := device.(types.BaseVirtualEthernetCard)
+				if !ok {
+					continue
+				}
+				card := nic.GetVirtualEthernetCard()
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%s;%s;%s;%s;%s;%s\n",
+					safeValue(vm.Summary.Config.Name),
+					safeValue(vm.Summary.Vm.Value),
+					safeValue(deviceLabel(d)),
+					safeValue(d.Key),
+					safeValue(strings.ToLower(strings.TrimPrefix(devices.TypeName(device), "Virtual"))),
+					safeValue(card.MacAddress),
+					safeValue(card.AddressType),
+					safeValue(nicPortgroup(d.Backing, portgroupNames)),
+					deviceConnected(d),
+					deviceStartConnected(d),
+				)
+			case "cdroms":
+				if _, ok := device.(*types.VirtualCdrom); !ok {
+					continue
+				}
+				backingType, backing := cdromBacking(d.Backing)
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%s;%s;%s;%s;%s;%s\n",
+					safeValue(vm.Summary.Config.Name),
+					safeValue(vm.Summary.Vm.Value),
+					safeValue(deviceLabel(d)),
+					safeValue(d.Key),
+					safeValue(controllerLabel(devices, d)),
+					unitNumber(d),
+					safeValue(backingType),
+					safeValue(backing),
+					deviceConnected(d),
+					deviceStartConnected(d),
+				)
+			case "controllers":
+				controller, ok := device.(types.BaseVirtualController)
+				if !ok {
+					continue
+				}
+				ctrl := controller.GetVirtualController()
+				sharedBus := "NA"
+				if scsi, ok := device.(types.BaseVirtualSCSIController); ok {
+					sharedBus = string(scsi.GetVirtualSCSIController().SharedBus)
+				}
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%s;%d;%s;%d\n",
+					safeValue(vm.Summary.Config.Name),
+					safeValue(vm.Summary.Vm.Value),
+					safeValue(deviceLabel(d)),
+					safeValue(d.Key),
+					safeValue(devices.Type(device)),
+					safeValue(ctrl.BusNumber),
+					safeValue(sharedBus),
+					len(ctrl.Device),
+				)
+			default:
+				disk, ok := device.(*types.VirtualDisk)
+				if !ok {
+					continue
+				}
+				provisioning, fileName, diskMode := diskBacking(disk.Backing)
+				datastore := "NA"
+				var dsPath object.DatastorePath
+				if dsPath.FromString(fileName) {
+					datastore = dsPath.Datastore
+				}
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%d;%s;%s;%s;%s;%s;%s\n",
+					safeValue(vm.Summary.Config.Name),
+					safeValue(vm.Summary.Vm.Value),
+					safeValue(deviceLabel(d)),
+					safeValue(d.Key),
+					safeValue(disk.CapacityInBytes),
+					safeValue(provisioning),
+					safeValue(datastore),
+					safeValue(fileName),
+					safeValue(controllerLabel(devices, d)),
+					unitNumber(d),
+					safeValue(diskMode),
+				)
+			}
+		}
+	}
+	if !vmFound {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "VM not found.")
+		os.Exit(1)
+	}
+	return nil
+}
+
+func getPortgroupNames(ctx context.Context, c *vim25.Client) (map[string]string, error) {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"DistributedVirtualPortgroup"}, true)
+	if err != nil {
+		return nil, err
+	}
+	defer v.Destroy(ctx)
+
+	var pgs []mo.DistributedVirtualPortgroup
+	err = v.Retrieve(ctx, []string{"DistributedVirtualPortgroup"}, []string{"name", "key"}, &pgs)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string)
+	for _, pg := range pgs {
+		names[pg.Key] = pg.Name
+	}
+	return names, nil
+}
+
+func deviceLabel(d *types.VirtualDevice) string {
+	if d.DeviceInfo == nil {
+		return "NA"
+	}
+	return d.DeviceInfo.GetDescription().Label
+}
+
+func controllerLabel(devices object.VirtualDeviceList, d *types.VirtualDevice) string {
+	controller := devices.FindByKey(d.ControllerKey)
+	if controller == nil {
+		return "NA"
+	}
+	return deviceLabel(controller.GetVirtualDevice())
+}
+
+func unitNumber(d *types.VirtualDevice) string {
+	if d.UnitNumber == nil {
+		return "NA"
+	}
+	return fmt.Sprintf("%d", *d.UnitNumber)
+}
+
+func deviceConnected(d *types.VirtualDevice) string {
+	if d.Connectable == nil {
+		return "NA"
+	}
+	return fmt.Sprintf("%t", d.Connectable.Connected)
+}
+
+func deviceStartConnected(d *types.VirtualDevice) string {
+	if d.Connectable == nil {
+		return "NA"
+	}
+	return fmt.Sprintf("%t", d.Connectable.StartConnected)
+}
+
+// diskBacking returns the provisioning type, file name and disk mode of a virtual disk backing
+func diskBacking(backing types.BaseVirtualDeviceBackingInfo) (string, string, string) {
+	switch b := backing.(type) {
+	case *types.VirtualDiskFlatVer2BackingInfo:
+		provisioning := "thickLazyZeroed"
+		if b.ThinProvisioned != nil && *b.ThinProvisioned {
+			provisioning = "thin"
+		} else if b.EagerlyScrub != nil && *b.EagerlyScrub {
+			provisioning = "thickEagerZeroed"
+		}
+		return provisioning, b.FileName, b.DiskMode
+	case *types.VirtualDiskSeSparseBackingInfo:
+		return "seSparse", b.FileName, b.DiskMode
+	case *types.VirtualDiskSparseVer2BackingInfo:
+		return "sparse", b.FileName, b.DiskMode
+	case *types.VirtualDiskRawDiskMappingVer1BackingInfo:
+		return "rdm-" + b.CompatibilityMode, b.FileName, b.DiskMode
+	case types.BaseVirtualDeviceFileBackingInfo:
+		return "NA", b.GetVirtualDeviceFileBackingInfo().FileName, "NA"
+	default:
+		return "NA", "NA", "NA"
+	}
+}
+
+// cdromBacking returns the backing type and the ISO file or host device of a CD-ROM
+func cdromBacking(backing types.BaseVirtualDeviceBackingInfo) (string, string) {
+	switch b := backing.(type) {
+	case *types.VirtualCdromIsoBackingInfo:
+		return "iso", b.FileName
+	case *types.VirtualCdromRemotePassthroughBackingInfo:
+		return "clientDevice", b.DeviceName
+	case *types.VirtualCdromRemoteAtapiBackingInfo:
+		return "clientDevice", b.DeviceName
+	case *types.VirtualCdromAtapiBackingInfo:
+		return "hostDevice", b.DeviceName
+	case *types.VirtualCdromPassthroughBackingInfo:
+		return "hostDevice", b.DeviceName
+	default:
+		return "NA", "NA"
+	}
+}
+
+func nicPortgroup(backing types.BaseVirtualDeviceBackingInfo, portgroupNames map[string]string) string {
+	switch b := backing.(type) {
+	case *types.VirtualEthernetCardNetworkBackingInfo:
+		return b.DeviceName
+	case *types.VirtualEthernetCardDistributedVirtualPortBackingInfo:
+		if name, ok := portgroupNames[b.Port.PortgroupKey]; ok {
+			return name
+		}
+		return b.Port.PortgroupKey
+	case *types.VirtualEthernetCardOpaqueNetworkBackingInfo:
+		return b.OpaqueNetworkId
+	default:
+		return "NA"
+	}
+}
@@ -13,10 +13,20 @@ func showClusterStatusError(errorText string) {
 	os.Exit(0)
 }
 
+// datastoreStatusHeader is shared by the datastore status rows and the error row, new columns go before proxyStatus
+const datastoreStatusHeader = "name;type;maintenanceMode;capacity;freeSpace;uncommitted;accessible;mountedOn;mountedOnInternal;provisioned;overcommitRatio;percentUsed;percentProvisioned;inaccessibleOn;proxyStatus\n"
+
 func showDatastoreStatusError(errorText string) {
-	fmt.Fprint(os.Stdout, "name;host;internalHostname;type;maintenanceMode;capacity;freeSpace;uncommitted;accessible;proxyStatus\n")
-	fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%s;%v;%s;%s\n",
-		"NA", "NA", "NA", "NA", "NA", "NA", "NA", 0, "NA", errorText)
+	fmt.Fprint(os.Stdout, datastoreStatusHeader)
+	fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%d;%d;%s;%s;%s;%d;%.2f;%.2f;%.2f;%s;%s\n",
+		datastoreFlag, "NA", "NA", 0, 0, 0, "NA", "NA", "NA", 0, 0.0, 0.0, 0.0, "NA", errorText)
+	os.Exit(0)
+}
+
+func showDatastoreClusterStatusError(errorText string) {
+	fmt.Fprint(os.Stdout, "name;internalName;numDatastores;capacity;freeSpace;uncommitted;provisioned;overcommitRatio;percentUsed;percentProvisioned;overallStatus;proxyStatus\n")
+	fmt.Fprintf(os.Stdout, "%s;%s;%d;%d;%d;%d;%d;%.2f;%.2f;%.2f;%s;%s\n",
+		datastoreClusterFlag, "NA", 0, 0, 0, 0, 0, 0.0, 0.0, 0.0, "NA", errorText)
 	os.Exit(0)
 }
 
@@ -40,3 +50,66 @@ func showHostStatusError(errorText string) {
 		"NA", 0, "NA", "NA", false, "NA", "NA", "NA", errorText)
 	os.Exit(0)
 }
+
+func showDVSStatusError(errorText string) {
+	fmt.Fprint(os.Stdout, "name;internalName;numPorts;portsInUse;numHosts;hostsNotUp;overallStatus;proxyStatus\n")
+	fmt.Fprintf(os.Stdout, "%s;%s;%d;%d;%d;%d;%s;%s\n",
+		dvsFlag, "NA", 0, 0, 0, 0, "NA", errorText)
+	os.Exit(0)
+}
+
+func showPortgroupStatusError(errorText string) {
+	fmt.Fprint(os.Stdout, "name;internalName;dvs;numPorts;portsInUse;overallStatus;proxyStatus\n")
+	fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%d;%s;%s\n",
+		portgroupFlag, "NA", "NA", 0, 0, "NA", errorText)
+	os.Exit(0)
+}
+
+func showVsanStatusError(errorText string) {
+	fmt.Fprint(os.Stdout, "cluster;vsanEnabled;numHosts;numMembers;numHostsNotInVsan;hostsNotInVsan;capacity;freeSpace;numHostsStatusError;hostsStatusError;proxyStatus\n")
+	fmt.Fprintf(os.Stdout, "%s;%v;%d;%d;%d;%s;%d;%d;%d;%s;%s\n",
+		clusterFlag, false, 0, 0, 0, "NA", 0, 0, 0, "NA", errorText)
+	os.Exit(0)
+}
+
+func showVAppStatusError(errorText string) {
+	fmt.Fprint(os.Stdout, "name;internalName;vAppState;numVms;numVmsPoweredOn;numVmsPoweredOff;numVmsSuspended;overallStatus;proxyStatus\n")
+	fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%d;%d;%d;%s;%s\n",
+		vappFlag, "NA", "NA", 0, 0, 0, 0, "NA", errorText)
+	os.Exit(0)
+}
+
+func showLicenseStatusError(errorText string) {
+	fmt.Fprint(os.Stdout, "name;licenseKey;edition;total;used;expirationDate;daysToExpire;expiringSoon;proxyStatus\n")
+	fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%d;%s;%s;%t;%s\n",
+		"NA", "NA", "NA", 0, 0, "NA", "NA", false, errorText)
+	os.Exit(0)
+}
+
+// showStatusError prints the error row matching the entity selected in the status command
+func showStatusError(errorText string) {
+	switch {
+	case hostFlag != "":
+		showHostStatusError(errorText)
+	case vmFlag != "":
+		showVMStatusError(errorText)
+	case clusterFlag != "" && vsanFlag:
+		showVsanStatusError(errorText)
+	case clusterFlag != "":
+		showClusterStatusError(errorText)
+	case datastoreFlag != "":
+		showDatastoreStatusError(errorText)
+	case resourcePoolFlag != "":
+		showResourcePoolStatusError(errorText)
+	case datastoreClusterFlag != "":
+		showDatastoreClusterStatusError(errorText)
+	case dvsFlag != "":
+		showDVSStatusError(errorText)
+	case portgroupFlag != "":
+		showPortgroupStatusError(errorText)
+	case vappFlag != "":
+		showVAppStatusError(errorText)
+	case licenseStatusFlag:
+		showLicenseStatusError(errorText)
+	}
+}
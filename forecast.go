@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+	"time"
+)
+
+// forecastInterval returns the historical interval that covers the forecast window
+func forecastInterval(window time.Duration) int32 {
+	switch {
+	case window <= 24*time.Hour:
+		return 300
+	case window <= 7*24*time.Hour:
+		return 1800
+	case window <= 30*24*time.Hour:
+		return 7200
+	default:
+		return 86400
+	}
+}
+
+func GetDatastoreForecast(ctx context.Context, c *vim25.Client) error {
+	// the datastores are checked before building the perf spec, a query without entities fails
+	dss, err := getDatastores(ctx, c, []string{"summary", "host"})
+	if err != nil {
+		return err
+	}
+	if len(dss) == 0 {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Datastore not found.")
+		os.Exit(1)
+	}
+
+	now, err := methods.GetCurrentTime(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	targetDate := now.Add(30 * 24 * time.Hour)
+	if targetDateFlag != "" {
+		targetDate, err = time.ParseInLocation("2006-01-02", targetDateFlag, now.Location())
+		if err != nil {
+			return fmt.Errorf("invalid target date %s, expected format is YYYY-MM-DD", targetDateFlag)
+		}
+	}
+
+	metric := "disk." + forecastMetricFlag + ".latest"
+	interval := forecastInterval(windowFlag)
+	startTime := now.Add(-windowFlag)
+
+	var refs []types.ManagedObjectReference
+	for _, ds := range dss {
+		refs = append(refs, ds.Self)
+	}
+
+	perfManager := performance.NewManager(c)
+	spec := types.PerfQuerySpec{
+		StartTime:  &startTime,
+		EndTime:    now,
+		MaxSample:  int32(windowFlag.Seconds())/interval + 1,
+		MetricId:   []types.PerfMetricId{{Instance: ""}},
+		IntervalId: interval,
+	}
+
+	sample, err := perfManager.SampleByName(ctx, spec, []string{metric}, refs)
+	if err != nil {
+		return err
+	}
+
+	result, err := perfManager.ToMetricSeries(ctx, sample)
+	if err != nil {
+		return err
+	}
+
+	series := make(map[string]performance.EntityMetric)
+	for _, r := range result {
+		series[r.Entity.Value] = r
+	}
+
+	fmt.Fprint(os.Stdout, "name;internalName;metric;samples;capacity;current;growthPerDay;daysUntilFull;targetDate;projectedAtTarget;projectedPercentAtTarget\n")
+
+	for _, ds := range dss {
+
+		var xs, ys []float64
+		if r, ok := series[ds.Self.Value]; ok && len(r.Value) > 0 {
+			for i, value := range r.Value[0].Value {
+				if i >= len(r.SampleInfo) || value < 0 {
+					continue
+				}
+				// x in days since the start of the window, y in bytes (counter unit is KB)
+				xs = append(xs, r.SampleInfo[i].Timestamp.Sub(startTime).Hours()/24)
+				ys = append(ys, float64(value)*1024)
+			}
+		}
+
+		slope, intercept, err := linearFit(xs, ys)
+		if err != nil {
+			fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%d;%s;%s;%s;%s;%s;%s\n",
+				safeValue(ds.Summary.Name),
+				safeValue(ds.Self.Value),
+				metric,
+				len(xs),
+				safeValue(ds.Summary.Capacity),
+				"NA", "NA", "NA",
+				targetDate.Format("2006-01-02"),
+				"NA", "NA")
+			continue
+		}
+
+		capacity := float64(ds.Summary.Capacity)
+		current := intercept + slope*now.Sub(startTime).Hours()/24
+		projected := intercept + slope*targetDate.Sub(startTime).Hours()/24
+		if projected < 0 {
+			projected = 0
+		}
+
+		// a flat or shrinking trend never fills the datastore
+		daysUntilFull := -1.0
+		if slope > 0 {
+			daysUntilFull = (capacity - current) / slope
+			if daysUntilFull < 0 {
+				daysUntilFull = 0
+			}
+		}
+
+		projectedPercent := 0.0
+		if capacity > 0 {
+			projectedPercent = projected * 100 / capacity
+		}
+
+		fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%d;%.0f;%.0f;%.2f;%s;%.0f;%.2f\n",
+			safeValue(ds.Summary.Name),
+			safeValue(ds.Self.Value),
+			metric,
+			len(xs),
+			safeValue(ds.Summary.Capacity),
+			current,
+			slope,
+			daysUntilFull,
+			targetDate.Format("2006-01-02"),
+			projected,
+			projectedPercent,
+		)
+	}
+	return nil
+}
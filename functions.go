@@ -3,10 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
+	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/view"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -114,6 +116,28 @@ func getHostNames(ctx context.Context, c *vim25.Client, name string) ([]string,
 	return hostNames, fmt.Errorf("host %s not found", name)
 }
 
+// getEntityNames returns a map of internal name (moref value) to name for all entities of the given type
+func getEntityNames(ctx context.Context, c *vim25.Client, entityType string) (map[string]string, error) {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{entityType}, true)
+	if err != nil {
+		return nil, err
+	}
+	defer v.Destroy(ctx)
+
+	var entities []mo.ManagedEntity
+	err = v.Retrieve(ctx, []string{entityType}, []string{"name"}, &entities)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string)
+	for _, entity := range entities {
+		names[entity.Self.Value] = entity.Name
+	}
+	return names, nil
+}
+
 func parseCSV(csv string) ([]float64, error) {
 	parts := strings.Split(csv, ",")
 	values := make([]float64, 0, len(parts))
@@ -158,3 +182,120 @@ func applyFunction(values []float64, function string) (float64, error) {
 		return 0, fmt.Errorf("unknown function: %s", function)
 	}
 }
+
+// linearFit returns the slope and intercept of the least squares line through the points (xs, ys)
+func linearFit(xs []float64, ys []float64) (float64, float64, error) {
+	n := float64(len(xs))
+	if len(xs) < 2 || len(xs) != len(ys) {
+		return 0, 0, fmt.Errorf("not enough samples to fit a trend")
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+		sumXY += xs[i] * ys[i]
+		sumXX += xs[i] * xs[i]
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, 0, fmt.Errorf("samples do not span any time")
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	intercept := (sumY - slope*sumX) / n
+	return slope, intercept, nil
+}
+
+// datastoreUsage computes the provisioned space and the usage ratios of a datastore from its summary values
+func datastoreUsage(capacity int64, freeSpace int64, uncommitted int64) (int64, float64, float64, float64) {
+	provisioned := capacity - freeSpace + uncommitted
+	if capacity == 0 {
+		return provisioned, 0, 0, 0
+	}
+	overcommitRatio := float64(provisioned) / float64(capacity)
+	percentUsed := float64(capacity-freeSpace) * 100 / float64(capacity)
+	percentProvisioned := float64(provisioned) * 100 / float64(capacity)
+	return provisioned, overcommitRatio, percentUsed, percentProvisioned
+}
+
+// getDatastores returns the datastores selected by the datastore flag, matching the name (wildcards allowed),
+// the internal name, the URL or the inventory path, and mounted on the hosts of the mountedOn flag when set.
+// props must include "summary" and "host".
+func getDatastores(ctx context.Context, c *vim25.Client, props []string) ([]mo.Datastore, error) {
+	var hostNames []string
+	var err error
+	if mountedOnFlag != "" {
+		hostNames, err = getHostNames(ctx, c, mountedOnFlag)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// inventory paths are resolved by the finder
+	inventoryRefs := make(map[string]bool)
+	if strings.HasPrefix(datastoreFlag, "/") {
+		list, err := find.NewFinder(c).DatastoreList(ctx, datastoreFlag)
+		if err != nil {
+			return nil, err
+		}
+		for _, ds := range list {
+			inventoryRefs[ds.Reference().Value] = true
+		}
+	}
+
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"Datastore"}, true)
+	if err != nil {
+		return nil, err
+	}
+	defer v.Destroy(ctx)
+
+	var dss []mo.Datastore
+	err = v.Retrieve(ctx, []string{"Datastore"}, props, &dss)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []mo.Datastore
+	for _, ds := range dss {
+		matched := ds.Self.Value == datastoreFlag || ds.Summary.Url == datastoreFlag || inventoryRefs[ds.Self.Value]
+		if !matched {
+			matched, _ = path.Match(datastoreFlag, ds.Summary.Name)
+		}
+		if !matched {
+			continue
+		}
+
+		if mountedOnFlag != "" {
+			var internalHostValues []string
+			for _, host := range ds.Host {
+				internalHostValues = append(internalHostValues, host.Key.Value)
+			}
+			if !containsAny(hostNames, internalHostValues) {
+				continue
+			}
+		}
+		selected = append(selected, ds)
+	}
+	return selected, nil
+}
+
+// datastoreMounts returns the names and internal names of the hosts mounting the datastore, the hosts where
+// the mount is not accessible and a host=accessMode:accessibility entry per mount
+func datastoreMounts(ds mo.Datastore, hostNames map[string]string) ([]string, []string, []string, []string) {
+	var names, internalNames, inaccessible, mounts []string
+	for _, host := range ds.Host {
+		name := hostNames[host.Key.Value]
+		names = append(names, name)
+		internalNames = append(internalNames, host.Key.Value)
+
+		accessibility := "accessible"
+		if host.MountInfo.Accessible == nil || !*host.MountInfo.Accessible {
+			accessibility = "inaccessible"
+			inaccessible = append(inaccessible, name)
+		}
+		mounts = append(mounts, fmt.Sprintf("%s=%s:%s", name, host.MountInfo.AccessMode, accessibility))
+	}
+	return names, internalNames, inaccessible, mounts
+}
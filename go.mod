@@ -3,15 +3,14 @@ module itoss-vsphere-stats
 go 1.22.3
 
 require (
-	github.com/kr/pretty v0.3.0
 	github.com/spf13/cobra v1.8.1
 	github.com/vmware/govmomi v0.38.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/kr/text v0.2.0 // indirect
 	github.com/rogpeppe/go-internal v1.6.1 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 )
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"os"
+	"strings"
+)
+
+func GetVMGuest(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"VirtualMachine"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var vms []mo.VirtualMachine
+
+	err = v.RetrieveWithFilter(ctx, []string{"VirtualMachine"}, []string{"summary", "guest"}, &vms, property.Match{"name": vmFlag})
+
+	if err != nil {
+		return err
+	}
+
+	switch sectionFlag {
+	case "nics":
+		fmt.Fprint(os.Stdout, "name;internalName;deviceKey;macAddress;connected;network;ipAddresses\n")
+	case "disks":
+		fmt.Fprint(os.Stdout, "name;internalName;diskPath;filesystemType;capacity;freeSpace;percentFree\n")
+	default:
+		fmt.Fprint(os.Stdout, "name;internalName;guestState;toolsRunningStatus;toolsVersionStatus;toolsVersion;hostName;ipAddress;guestFamily;guestFullName\n")
+	}
+
+	vmFound := false
+	for _, vm := range vms {
+		vmFound = true
+
+		// guest is not set when the VM was never powered on
+		if vm.Guest == nil {
+			if sectionFlag == "summary" {
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%s;%s;%s;%s\n",
+					safeValue(vm.Summary.Config.Name),
+					safeValue(vm.Summary.Vm.Value),
+					"NA", "NA", "NA", "NA", "NA", "NA", "NA", "NA")
+			}
+			continue
+		}
+
+		switch sectionFlag {
+		case "nics":
+			for _, nic := range vm.Guest.Net {
+				fmt.Fprintf(os.Stdout, "%s;%s;%v;%s;%v;%s;%s\n",
+					safeValue(vm.Summary.Config.Name),
+					safeValue(vm.Summary.Vm.Value),
+					safeValue(nic.DeviceConfigId),
+					safeValue(nic.MacAddress),
+					safeValue(nic.Connected),
+					safeValue(nic.Network),
+					safeValue(strings.Join(nic.IpAddress, ",")),
+				)
+			}
+		case "disks":
+			for _, disk := range vm.Guest.Disk {
+				percentFree := 0.0
+				if disk.Capacity > 0 {
+					percentFree = float64(disk.FreeSpace) * 100 / float64(disk.Capacity)
+				}
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%v;%v;%.2f\n",
+					safeValue(vm.Summary.Config.Name),
+					safeValue(vm.Summary.Vm.Value),
+					safeValue(disk.DiskPath),
+					safeValue(disk.FilesystemType),
+					safeValue(disk.Capacity),
+					safeValue(disk.FreeSpace),
+					percentFree,
+				)
+			}
+		default:
+			fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%s;%s;%s;%s\n",
+				safeValue(vm.Summary.Config.Name),
+				safeValue(vm.Summary.Vm.Value),
+				safeValue(vm.Guest.GuestState),
+				safeValue(vm.Guest.ToolsRunningStatus),
+				safeValue(vm.Guest.ToolsVersionStatus2),
+				safeValue(vm.Guest.ToolsVersion),
+				safeValue(vm.Guest.HostName),
+				safeValue(vm.Guest.IpAddress),
+				safeValue(vm.Guest.GuestFamily),
+				safeValue(vm.Guest.GuestFullName),
+			)
+		}
+	}
+	if !vmFound {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "VM not found.")
+		os.Exit(1)
+	}
+	return nil
+}
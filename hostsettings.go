@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+func GetHostsSettings(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"HostSystem"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var hss []mo.HostSystem
+
+	err = v.RetrieveWithFilter(ctx, []string{"HostSystem"}, []string{"name", "config.product", "config.dateTimeInfo", "config.network.dnsConfig", "config.service", "config.firewall", "config.option", "configManager.advancedOption", "configManager.serviceSystem"}, &hss, property.Match{"name": hostFlag})
+	if err != nil {
+		return err
+	}
+
+	switch sectionFlag {
+	case "services":
+		fmt.Fprint(os.Stdout, "host;key;label;running;policy;required;rulesets\n")
+	case "time":
+		fmt.Fprint(os.Stdout, "host;timeZone;protocol;ntpServers;ntpdRunning;ntpdPolicy\n")
+	case "dns":
+		fmt.Fprint(os.Stdout, "host;hostName;domainName;dhcp;servers;searchDomains\n")
+	case "syslog":
+		fmt.Fprint(os.Stdout, "host;logHost;logDir;logDirUnique;defaultRotate;defaultSize\n")
+	case "firewall":
+		fmt.Fprint(os.Stdout, "host;ruleset;label;enabled;required;service;allowedAllIp;allowedHosts;rules\n")
+	case "advanced":
+		fmt.Fprint(os.Stdout, "host;key;value\n")
+	}
+
+	withServices := sectionFlag == "services" || sectionFlag == "time"
+	withOptions := sectionFlag == "syslog" || sectionFlag == "advanced"
+
+	hostFound := false
+	for _, hs := range hss {
+		hostFound = true
+		if hs.Config == nil {
+			continue
+		}
+
+		// services and options come from the host service system and option manager
+		settings, err := getHostSettings(ctx, c, hs, withServices, withOptions)
+		if err != nil {
+			return err
+		}
+		option := func(key string) string {
+			if value, ok := settings.options[key]; ok {
+				return value
+			}
+			return "NA"
+		}
+
+		switch sectionFlag {
+		case "services":
+			var keys []string
+			for key := range settings.services {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				service := settings.services[key]
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%t;%s;%t;%s\n",
+					safeValue(hs.Name),
+					safeValue(service.Key),
+					safeValue(service.Label),
+					service.Running,
+					safeValue(service.Policy),
+					service.Required,
+					safeValue(strings.Join(service.Ruleset, ",")),
+				)
+			}
+		case "time":
+			timeZone, protocol := "NA", "NA"
+			if hs.Config.DateTimeInfo != nil {
+				timeZone = hs.Config.DateTimeInfo.TimeZone.Name
+				protocol = hs.Config.DateTimeInfo.SystemClockProtocol
+			}
+			ntpdRunning, ntpdPolicy := "NA", "NA"
+			if ntpd, ok := settings.services["ntpd"]; ok {
+				ntpdRunning = fmt.Sprintf("%t", ntpd.Running)
+				ntpdPolicy = ntpd.Policy
+			}
+			fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s\n",
+				safeValue(hs.Name),
+				safeValue(timeZone),
+				safeValue(protocol),
+				safeValue(strings.Join(settings.ntpServers, ",")),
+				safeValue(ntpdRunning),
+				safeValue(ntpdPolicy),
+			)
+		case "dns":
+			if hs.Config.Network == nil || hs.Config.Network.DnsConfig == nil {
+				continue
+			}
+			dns := hs.Config.Network.DnsConfig.GetHostDnsConfig()
+			fmt.Fprintf(os.Stdout, "%s;%s;%s;%t;%s;%s\n",
+				safeValue(hs.Name),
+				safeValue(dns.HostName),
+				safeValue(dns.DomainName),
+				dns.Dhcp,
+				safeValue(strings.Join(dns.Address, ",")),
+				safeValue(strings.Join(dns.SearchDomain, ",")),
+			)
+		case "syslog":
+			fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s\n",
+				safeValue(hs.Name),
+				safeValue(option("Syslog.global.logHost")),
+				safeValue(option("Syslog.global.logDir")),
+				safeValue(option("Syslog.global.logDirUnique")),
+				safeValue(option("Syslog.global.defaultRotate")),
+				safeValue(option("Syslog.global.defaultSize")),
+			)
+		case "firewall":
+			if hs.Config.Firewall == nil {
+				continue
+			}
+			for _, ruleset := range hs.Config.Firewall.Ruleset {
+				allowedAllIp, allowedHosts := "NA", "NA"
+				if ruleset.AllowedHosts != nil {
+					allowedAllIp = fmt.Sprintf("%t", ruleset.AllowedHosts.AllIp)
+					hosts := append([]string(nil), ruleset.AllowedHosts.IpAddress...)
+					for _, network := range ruleset.AllowedHosts.IpNetwork {
+						hosts = append(hosts, fmt.Sprintf("%s/%d", network.Network, network.PrefixLength))
+					}
+					allowedHosts = strings.Join(hosts, ",")
+				}
+				// rules are formatted as direction/protocol/port[-endPort]
+				var rules []string
+				for _, rule := range ruleset.Rule {
+					port := fmt.Sprintf("%d", rule.Port)
+					if rule.EndPort != 0 {
+						port = fmt.Sprintf("%d-%d", rule.Port, rule.EndPort)
+					}
+					rules = append(rules, fmt.Sprintf("%s/%s/%s", rule.Direction, rule.Protocol, port))
+				}
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%t;%t;%s;%s;%s;%s\n",
+					safeValue(hs.Name),
+					safeValue(ruleset.Key),
+					safeValue(ruleset.Label),
+					ruleset.Enabled,
+					ruleset.Required,
+					safeValue(ruleset.Service),
+					safeValue(allowedAllIp),
+					safeValue(allowedHosts),
+					safeValue(strings.Join(rules, ",")),
+				)
+			}
+		case "advanced":
+			var keys []string
+			for key := range settings.options {
+				if matched, _ := path.Match(optionKeyFlag, key); matched {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				fmt.Fprintf(os.Stdout, "%s;%s;%s\n",
+					safeValue(hs.Name),
+					safeValue(key),
+					safeValue(settings.options[key]),
+				)
+			}
+		}
+	}
+	if !hostFound {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Host not found.")
+		os.Exit(1)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/license"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// maskLicenseKey hides all but the last group of a license key
+func maskLicenseKey(key string) string {
+	groups := strings.Split(key, "-")
+	for i := 0; i < len(groups)-1; i++ {
+		groups[i] = strings.Repeat("X", len(groups[i]))
+	}
+	return strings.Join(groups, "-")
+}
+
+// licenseExpiration returns the expiration date of a license, nil for perpetual licenses
+func licenseExpiration(info types.LicenseManagerLicenseInfo) *time.Time {
+	for _, p := range info.Properties {
+		if p.Key != "expirationDate" {
+			continue
+		}
+		if expiration, ok := p.Value.(time.Time); ok {
+			return &expiration
+		}
+	}
+	return nil
+}
+
+func getLicenseAssignments(ctx context.Context, m *license.Manager) (map[string][]string, error) {
+	assignments := make(map[string][]string)
+	am, err := m.AssignmentManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	assigned, err := am.QueryAssigned(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range assigned {
+		name := a.EntityDisplayName
+		if name == "" {
+			name = a.EntityId
+		}
+		assignments[a.AssignedLicense.LicenseKey] = append(assignments[a.AssignedLicense.LicenseKey], name)
+	}
+	return assignments, nil
+}
+
+func GetLicenses(ctx context.Context, c *vim25.Client) error {
+	m := license.NewManager(c)
+	licenses, err := m.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	assignments, err := getLicenseAssignments(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(os.Stdout, "name;licenseKey;edition;total;used;costUnit;expirationDate;assignedEntities\n")
+	for _, l := range licenses {
+		fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%d;%s;%s;%s\n",
+			safeValue(l.Name),
+			safeValue(maskLicenseKey(l.LicenseKey)),
+			safeValue(l.EditionKey),
+			l.Total,
+			l.Used,
+			safeValue(l.CostUnit),
+			safeValue(licenseExpiration(l)),
+			safeValue(strings.Join(assignments[l.LicenseKey], ",")),
+		)
+	}
+	return nil
+}
+
+func GetLicensesStatus(ctx context.Context, c *vim25.Client) error {
+	m := license.NewManager(c)
+	licenses, err := m.List(ctx)
+	if err != nil {
+		showLicenseStatusError(err.Error())
+	}
+	if len(licenses) == 0 {
+		showLicenseStatusError("LICENSE_NOT_FOUND")
+	}
+
+	now, err := methods.GetCurrentTime(ctx, c)
+	if err != nil {
+		showLicenseStatusError(err.Error())
+	}
+
+	fmt.Fprint(os.Stdout, "name;licenseKey;edition;total;used;expirationDate;daysToExpire;expiringSoon;proxyStatus\n")
+	for _, l := range licenses {
+		expiration := licenseExpiration(l)
+		daysToExpire, expiringSoon := "NA", false
+		if expiration != nil {
+			days := int(math.Floor(expiration.Sub(*now).Hours() / 24))
+			daysToExpire = fmt.Sprintf("%d", days)
+			expiringSoon = days <= expiringDaysFlag
+		}
+
+		fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%d;%s;%s;%t;%s\n",
+			safeValue(l.Name),
+			safeValue(maskLicenseKey(l.LicenseKey)),
+			safeValue(l.EditionKey),
+			l.Total,
+			l.Used,
+			safeValue(expiration),
+			daysToExpire,
+			expiringSoon,
+			"OK")
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// metricEntityTypes are the entity types sampled to build the counter catalogue
+var metricEntityTypes = []string{"VirtualMachine", "HostSystem", "Datastore", "ClusterComputeResource", "ResourcePool"}
+
+// metricCatalogueEntry describes a performance counter and the entity types it applies to
+type metricCatalogueEntry struct {
+	Metric      string   `json:"metric"`
+	Key         int32    `json:"key"`
+	Group       string   `json:"group"`
+	Name        string   `json:"name"`
+	Rollup      string   `json:"rollup"`
+	Level       int32    `json:"level"`
+	Unit        string   `json:"unit"`
+	Description string   `json:"description"`
+	EntityTypes []string `json:"entityTypes"`
+}
+
+// metricCatalogueFile returns the cache file of the catalogue, one per endpoint. Standalone ESXi
+// hosts have no instance uuid, so the endpoint host is always part of the key.
+func metricCatalogueFile(c *vim25.Client) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	key := c.URL().Host
+	if uuid := c.ServiceContent.About.InstanceUuid; uuid != "" {
+		key = uuid + "-" + key
+	}
+	key = strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, key)
+	return filepath.Join(dir, "itoss-vsphere", "metrics-"+key+".json"), nil
+}
+
+func buildMetricCatalogue(ctx context.Context, c *vim25.Client) ([]metricCatalogueEntry, error) {
+	perfManager := performance.NewManager(c)
+	counters, err := perfManager.CounterInfoByKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, metricEntityTypes, true)
+	if err != nil {
+		return nil, err
+	}
+	defer v.Destroy(ctx)
+
+	// a single representative entity per type is enough to know which counters apply
+	entityTypes := make(map[int32][]string)
+	for _, entityType := range metricEntityTypes {
+		refs, err := v.Find(ctx, []string{entityType}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(refs) == 0 {
+			continue
+		}
+
+		summary, err := perfManager.ProviderSummary(ctx, refs[0])
+		if err != nil {
+			return nil, err
+		}
+		var intervals []int32
+		if summary.CurrentSupported {
+			intervals = append(intervals, summary.RefreshRate)
+		}
+		if summary.SummarySupported {
+			intervals = append(intervals, 300)
+		}
+
+		available := make(map[int32]bool)
+		for _, interval := range intervals {
+			metrics, err := perfManager.AvailableMetric(ctx, refs[0], interval)
+			if err != nil {
+				return nil, err
+			}
+			for _, metric := range metrics {
+				available[metric.CounterId] = true
+			}
+		}
+		for key := range available {
+			entityTypes[key] = append(entityTypes[key], entityType)
+		}
+	}
+
+	var catalogue []metricCatalogueEntry
+	for key, counter := range counters {
+		catalogue = append(catalogue, metricCatalogueEntry{
+			Metric:      counter.Name(),
+			Key:         key,
+			Group:       counter.GroupInfo.GetElementDescription().Key,
+			Name:        counter.NameInfo.GetElementDescription().Key,
+			Rollup:      string(counter.RollupType),
+			Level:       counter.Level,
+			Unit:        counter.UnitInfo.GetElementDescription().Label,
+			Description: counter.NameInfo.GetElementDescription().Summary,
+			EntityTypes: entityTypes[key],
+		})
+	}
+	sort.Slice(catalogue, func(i, j int) bool { return catalogue[i].Metric < catalogue[j].Metric })
+	return catalogue, nil
+}
+
+// getMetricCatalogue loads the catalogue from the local cache, building and caching it when missing
+func getMetricCatalogue(ctx context.Context, c *vim25.Client) ([]metricCatalogueEntry, error) {
+	file, err := metricCatalogueFile(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// the cache is rebuilt once it is older than --maxAge, counters change with vCenter upgrades
+	info, err := os.Stat(file)
+	if !refreshFlag && err == nil && time.Since(info.ModTime()) < cacheMaxAgeFlag {
+		data, err := os.ReadFile(file)
+		if err == nil {
+			var catalogue []metricCatalogueEntry
+			if json.Unmarshal(data, &catalogue) == nil {
+				return catalogue, nil
+			}
+		}
+	}
+
+	catalogue, err := buildMetricCatalogue(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	// the cache is an optimization, failing to write it must not fail the command
+	data, err := json.Marshal(catalogue)
+	if err == nil && os.MkdirAll(filepath.Dir(file), 0700) == nil {
+		_ = os.WriteFile(file, data, 0600)
+	}
+	return catalogue, nil
+}
+
+func ListMetrics(ctx context.Context, c *vim25.Client) error {
+	if sectionFlag == "intervals" {
+		return ListIntervals(ctx, c)
+	}
+
+	catalogue, err := getMetricCatalogue(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	pattern := metricsFlag
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	fmt.Fprint(os.Stdout, "metric;key;group;name;rollup;level;unit;description;entityTypes\n")
+	for _, entry := range catalogue {
+		if matched, _ := path.Match(pattern, entry.Metric); !matched {
+			continue
+		}
+		if entityTypeFlag != "" && !contains(entry.EntityTypes, entityTypeFlag) {
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "%s;%d;%s;%s;%s;%d;%s;%s;%s\n",
+			safeValue(entry.Metric),
+			entry.Key,
+			safeValue(entry.Group),
+			safeValue(entry.Name),
+			safeValue(entry.Rollup),
+			entry.Level,
+			safeValue(entry.Unit),
+			safeValue(strings.ReplaceAll(entry.Description, ";", ",")),
+			safeValue(strings.Join(entry.EntityTypes, ",")),
+		)
+	}
+	return nil
+}
+
+func ListIntervals(ctx context.Context, c *vim25.Client) error {
+	intervals, err := performance.NewManager(c).HistoricalInterval(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(os.Stdout, "key;name;samplingPeriod;length;level;enabled\n")
+	for _, interval := range intervals {
+		fmt.Fprintf(os.Stdout, "%d;%s;%d;%d;%d;%t\n",
+			interval.Key,
+			safeValue(interval.Name),
+			interval.SamplingPeriod,
+			interval.Length,
+			interval.Level,
+			interval.Enabled,
+		)
+	}
+	return nil
+}
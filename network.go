@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"os"
+	"strings"
+)
+
+func GetHostsNetwork(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"HostSystem"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var hss []mo.HostSystem
+
+	err = v.RetrieveWithFilter(ctx, []string{"HostSystem"}, []string{"summary", "config.network", "config.virtualNicManagerInfo"}, &hss, property.Match{"name": hostFlag})
+
+	if err != nil {
+		return err
+	}
+
+	switch sectionFlag {
+	case "vswitches":
+		fmt.Fprint(os.Stdout, "host;vswitch;numPorts;numPortsAvailable;mtu;uplinks;portgroups\n")
+	case "portgroups":
+		fmt.Fprint(os.Stdout, "host;portgroup;vswitch;vlanId;activePorts\n")
+	case "vmknics":
+		fmt.Fprint(os.Stdout, "host;device;portgroup;ipAddress;subnetMask;dhcp;mac;mtu;services\n")
+	default:
+		fmt.Fprint(os.Stdout, "host;device;driver;mac;linkUp;speedMb;duplex;pci\n")
+	}
+
+	hostFound := false
+	for _, hs := range hss {
+		hostFound = true
+		if hs.Config == nil || hs.Config.Network == nil {
+			continue
+		}
+		network := hs.Config.Network
+
+		// uplinks and portgroups are referenced by key from the vswitch
+		pnicNames := make(map[string]string)
+		for _, pnic := range network.Pnic {
+			pnicNames[pnic.Key] = pnic.Device
+		}
+		portgroupNames := make(map[string]string)
+		for _, pg := range network.Portgroup {
+			portgroupNames[pg.Key] = pg.Spec.Name
+		}
+
+		switch sectionFlag {
+		case "vswitches":
+			for _, vs := range network.Vswitch {
+				var uplinks, portgroups []string
+				for _, key := range vs.Pnic {
+					uplinks = append(uplinks, pnicNames[key])
+				}
+				for _, key := range vs.Portgroup {
+					portgroups = append(portgroups, portgroupNames[key])
+				}
+				fmt.Fprintf(os.Stdout, "%s;%s;%d;%d;%d;%s;%s\n",
+					safeValue(hs.Summary.Config.Name),
+					safeValue(vs.Name),
+					safeValue(vs.NumPorts),
+					safeValue(vs.NumPortsAvailable),
+					safeValue(vs.Mtu),
+					safeValue(strings.Join(uplinks, ",")),
+					safeValue(strings.Join(portgroups, ",")),
+				)
+			}
+		case "portgroups":
+			for _, pg := range network.Portgroup {
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%d\n",
+					safeValue(hs.Summary.Config.Name),
+					safeValue(pg.Spec.Name),
+					safeValue(pg.Spec.VswitchName),
+					safeValue(pg.Spec.VlanId),
+					len(pg.Port),
+				)
+			}
+		case "vmknics":
+			services := vmknicServices(hs)
+			for _, vnic := range network.Vnic {
+				portgroup := vnic.Portgroup
+				if portgroup == "" && vnic.Spec.DistributedVirtualPort != nil {
+					portgroup = vnic.Spec.DistributedVirtualPort.PortgroupKey
+				}
+				ipAddress, subnetMask, dhcp := "NA", "NA", "NA"
+				if vnic.Spec.Ip != nil {
+					ipAddress = vnic.Spec.Ip.IpAddress
+					subnetMask = vnic.Spec.Ip.SubnetMask
+					dhcp = fmt.Sprintf("%t", vnic.Spec.Ip.Dhcp)
+				}
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%s;%d;%s\n",
+					safeValue(hs.Summary.Config.Name),
+					safeValue(vnic.Device),
+					safeValue(portgroup),
+					safeValue(ipAddress),
+					safeValue(subnetMask),
+					safeValue(dhcp),
+					safeValue(vnic.Spec.Mac),
+					safeValue(vnic.Spec.Mtu),
+					safeValue(strings.Join(services[vnic.Device], ",")),
+				)
+			}
+		default:
+			for _, pnic := range network.Pnic {
+				linkUp, speedMb, duplex := false, int32(0), "NA"
+				if pnic.LinkSpeed != nil {
+					linkUp = true
+					speedMb = pnic.LinkSpeed.SpeedMb
+					duplex = "half"
+					if pnic.LinkSpeed.Duplex {
+						duplex = "full"
+					}
+				}
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%v;%d;%s;%s\n",
+					safeValue(hs.Summary.Config.Name),
+					safeValue(pnic.Device),
+					safeValue(pnic.Driver),
+					safeValue(pnic.Mac),
+					safeValue(linkUp),
+					safeValue(speedMb),
+					safeValue(duplex),
+					safeValue(pnic.Pci),
+				)
+			}
+		}
+	}
+	if !hostFound {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Host not found.")
+		os.Exit(1)
+	}
+	return nil
+}
+
+// vmknicServices maps each VMkernel adapter to the services (management, vmotion, vsan...) enabled on it
+func vmknicServices(hs mo.HostSystem) map[string][]string {
+	services := make(map[string][]string)
+	if hs.Config.VirtualNicManagerInfo == nil {
+		return services
+	}
+	for _, netConfig := range hs.Config.VirtualNicManagerInfo.NetConfig {
+		for _, vnic := range netConfig.CandidateVnic {
+			if contains(netConfig.SelectedVnic, vnic.Key) {
+				services[vnic.Device] = append(services[vnic.Device], netConfig.NicType)
+			}
+		}
+	}
+	return services
+}
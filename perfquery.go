@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultMaxQueryMetrics is the vCenter default of config.vpxd.stats.maxQueryMetrics
+const defaultMaxQueryMetrics = 64
+
+// getMaxQueryMetrics returns the maximum number of entity and metric pairs per query, the lowest of
+// --maxQueryMetrics and the vCenter config.vpxd.stats.maxQueryMetrics setting, 0 meaning no limit
+func getMaxQueryMetrics(ctx context.Context, c *vim25.Client) int {
+	// the limit is a vCenter setting, queries against an ESXi host are not limited
+	limit := 0
+	if c.IsVC() && c.ServiceContent.Setting != nil {
+		limit = defaultMaxQueryMetrics
+		options, err := object.NewOptionManager(c, *c.ServiceContent.Setting).Query(ctx, "config.vpxd.stats.maxQueryMetrics")
+		if err == nil && len(options) > 0 {
+			value, err := strconv.Atoi(fmt.Sprintf("%v", options[0].GetOptionValue().Value))
+			if err == nil {
+				limit = max(value, 0)
+			}
+		}
+	}
+
+	if maxQueryMetricsFlag > 0 && (limit == 0 || maxQueryMetricsFlag < limit) {
+		limit = maxQueryMetricsFlag
+	}
+	return limit
+}
+
+// expandWildcardInstances replaces the "*" instance of a counter with the instances available on
+// the entity, as vCenter counts every expanded instance against maxQueryMetrics
+func expandWildcardInstances(metricIds []types.PerfMetricId, available []types.PerfMetricId) []types.PerfMetricId {
+	var expanded []types.PerfMetricId
+	seen := make(map[types.PerfMetricId]bool)
+	add := func(id types.PerfMetricId) {
+		if !seen[id] {
+			seen[id] = true
+			expanded = append(expanded, id)
+		}
+	}
+	for _, id := range metricIds {
+		if id.Instance != "*" {
+			add(id)
+			continue
+		}
+		for _, a := range available {
+			if a.CounterId == id.CounterId {
+				add(types.PerfMetricId{CounterId: a.CounterId, Instance: a.Instance})
+			}
+		}
+	}
+	return expanded
+}
+
+// chunkPerfQuerySpecs splits the metrics of every entity so that no query exceeds limit entity and
+// metric pairs, then packs the specs into as few queries as possible. Entities without metrics
+// are skipped, a spec without metric ids would query every counter.
+func chunkPerfQuerySpecs(refs []types.ManagedObjectReference, metricIds map[string][]types.PerfMetricId, limit int) [][]types.PerfQuerySpec {
+	var chunks [][]types.PerfQuerySpec
+	var chunk []types.PerfQuerySpec
+	size := 0
+	for _, ref := range refs {
+		ids := metricIds[ref.Value]
+		metricsPerSpec := len(ids)
+		if limit > 0 && limit < metricsPerSpec {
+			metricsPerSpec = limit
+		}
+		for start := 0; start < len(ids); start += metricsPerSpec {
+			end := min(start+metricsPerSpec, len(ids))
+			if limit > 0 && size+end-start > limit {
+				chunks = append(chunks, chunk)
+				chunk, size = nil, 0
+			}
+			chunk = append(chunk, types.PerfQuerySpec{
+				Entity:     ref,
+				MaxSample:  int32(maxSamplesFlag),
+				MetricId:   ids[start:end],
+				IntervalId: int32(intervalFlag),
+			})
+			size += end - start
+		}
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// queryPerf runs the chunked queries with at most --concurrency queries in flight and returns
+// one series set per entity, in the order of refs
+func queryPerf(ctx context.Context, perfManager *performance.Manager, refs []types.ManagedObjectReference, metricIds []types.PerfMetricId, limit int) ([]performance.EntityMetric, error) {
+	wildcard := false
+	for _, id := range metricIds {
+		wildcard = wildcard || id.Instance == "*"
+	}
+
+	// wildcards only need to be resolved when the query size is limited
+	entityMetricIds := make(map[string][]types.PerfMetricId)
+	for _, ref := range refs {
+		entityMetricIds[ref.Value] = metricIds
+		if limit > 0 && wildcard {
+			available, err := perfManager.AvailableMetric(ctx, ref, int32(intervalFlag))
+			if err != nil {
+				return nil, err
+			}
+			entityMetricIds[ref.Value] = expandWildcardInstances(metricIds, available)
+		}
+	}
+	chunks := chunkPerfQuerySpecs(refs, entityMetricIds, limit)
+
+	results := make([][]performance.EntityMetric, len(chunks))
+	errs := make([]error, len(chunks))
+	semaphore := make(chan struct{}, max(1, concurrencyFlag))
+	var wg sync.WaitGroup
+	for i, specs := range chunks {
+		wg.Add(1)
+		go func(i int, specs []types.PerfQuerySpec) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			sample, err := perfManager.Query(ctx, specs)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results[i], errs[i] = perfManager.ToMetricSeries(ctx, sample)
+		}(i, specs)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// an entity split across queries gets its series merged back
+	merged := make(map[string]*performance.EntityMetric)
+	for _, result := range results {
+		for _, metric := range result {
+			if existing, ok := merged[metric.Entity.Value]; ok {
+				*existing = mergeEntityMetric(*existing, metric)
+				continue
+			}
+			metric := metric
+			merged[metric.Entity.Value] = &metric
+		}
+	}
+
+	var series []performance.EntityMetric
+	for _, ref := range refs {
+		if metric, ok := merged[ref.Value]; ok {
+			series = append(series, *metric)
+		}
+	}
+	return series, nil
+}
+
+// mergeEntityMetric combines the series of an entity returned by different queries. The chunks may
+// cover different sample windows, so the series are aligned on the union of the sample timestamps
+// and samples missing from a chunk are set to -1, the vSphere value for unavailable samples.
+func mergeEntityMetric(a performance.EntityMetric, b performance.EntityMetric) performance.EntityMetric {
+	var sampleInfo []types.PerfSampleInfo
+	seen := make(map[time.Time]bool)
+	for _, info := range append(append([]types.PerfSampleInfo(nil), a.SampleInfo...), b.SampleInfo...) {
+		if !seen[info.Timestamp] {
+			seen[info.Timestamp] = true
+			sampleInfo = append(sampleInfo, info)
+		}
+	}
+	sort.Slice(sampleInfo, func(i, j int) bool { return sampleInfo[i].Timestamp.Before(sampleInfo[j].Timestamp) })
+
+	index := make(map[time.Time]int)
+	for i, info := range sampleInfo {
+		index[info.Timestamp] = i
+	}
+	align := func(metric performance.EntityMetric) []performance.MetricSeries {
+		var series []performance.MetricSeries
+		for _, s := range metric.Value {
+			values := make([]int64, len(sampleInfo))
+			for i := range values {
+				values[i] = -1
+			}
+			for i, value := range s.Value {
+				if i < len(metric.SampleInfo) {
+					values[index[metric.SampleInfo[i].Timestamp]] = value
+				}
+			}
+			s.Value = values
+			series = append(series, s)
+		}
+		return series
+	}
+
+	return performance.EntityMetric{
+		Entity:     a.Entity,
+		SampleInfo: sampleInfo,
+		Value:      append(align(a), align(b)...),
+	}
+}
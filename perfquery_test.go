@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/vim25/types"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChunkPerfQuerySpecs(t *testing.T) {
+	// metrics holds the number of metric ids of each entity
+	entities := func(metrics []int) ([]types.ManagedObjectReference, map[string][]types.PerfMetricId) {
+		var refs []types.ManagedObjectReference
+		metricIds := make(map[string][]types.PerfMetricId)
+		for i, n := range metrics {
+			ref := types.ManagedObjectReference{Type: "HostSystem", Value: fmt.Sprintf("host-%d", i)}
+			refs = append(refs, ref)
+			for j := 0; j < n; j++ {
+				metricIds[ref.Value] = append(metricIds[ref.Value], types.PerfMetricId{CounterId: int32(j + 1)})
+			}
+		}
+		return refs, metricIds
+	}
+
+	// each chunk is described as the entity and metric count of its specs
+	tests := []struct {
+		name    string
+		metrics []int
+		limit   int
+		want    [][]string
+	}{
+		{
+			name:    "no limit",
+			metrics: []int{2, 2, 2},
+			limit:   0,
+			want:    [][]string{{"host-0:2", "host-1:2", "host-2:2"}},
+		},
+		{
+			name:    "limit 1",
+			metrics: []int{2, 2},
+			limit:   1,
+			want:    [][]string{{"host-0:1"}, {"host-0:1"}, {"host-1:1"}, {"host-1:1"}},
+		},
+		{
+			name:    "entities packed up to the limit",
+			metrics: []int{2, 2, 2},
+			limit:   5,
+			want:    [][]string{{"host-0:2", "host-1:2"}, {"host-2:2"}},
+		},
+		{
+			name:    "entity with more metrics than the limit",
+			metrics: []int{5},
+			limit:   2,
+			want:    [][]string{{"host-0:2"}, {"host-0:2"}, {"host-0:1"}},
+		},
+		{
+			name:    "every entity is split the same way",
+			metrics: []int{3, 3},
+			limit:   2,
+			want:    [][]string{{"host-0:2"}, {"host-0:1"}, {"host-1:2"}, {"host-1:1"}},
+		},
+		{
+			name:    "entities with different metric counts",
+			metrics: []int{3, 1},
+			limit:   2,
+			want:    [][]string{{"host-0:2"}, {"host-0:1", "host-1:1"}},
+		},
+		{
+			name:    "entity without metrics is skipped",
+			metrics: []int{0, 2},
+			limit:   0,
+			want:    [][]string{{"host-1:2"}},
+		},
+		{
+			name:    "no entities",
+			metrics: []int{},
+			limit:   2,
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs, metricIds := entities(tt.metrics)
+			chunks := chunkPerfQuerySpecs(refs, metricIds, tt.limit)
+
+			var got [][]string
+			for _, chunk := range chunks {
+				var specs []string
+				size := 0
+				for _, spec := range chunk {
+					specs = append(specs, fmt.Sprintf("%s:%d", spec.Entity.Value, len(spec.MetricId)))
+					size += len(spec.MetricId)
+				}
+				if tt.limit > 0 && size > tt.limit {
+					t.Errorf("chunk %v exceeds limit %d", specs, tt.limit)
+				}
+				got = append(got, specs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunkPerfQuerySpecs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandWildcardInstances(t *testing.T) {
+	available := []types.PerfMetricId{
+		{CounterId: 1, Instance: ""},
+		{CounterId: 1, Instance: "vmnic0"},
+		{CounterId: 1, Instance: "vmnic1"},
+		{CounterId: 2, Instance: ""},
+	}
+	tests := []struct {
+		name      string
+		metricIds []types.PerfMetricId
+		want      []types.PerfMetricId
+	}{
+		{
+			name:      "explicit instances are kept",
+			metricIds: []types.PerfMetricId{{CounterId: 2, Instance: ""}},
+			want:      []types.PerfMetricId{{CounterId: 2, Instance: ""}},
+		},
+		{
+			name:      "wildcard expands to every available instance",
+			metricIds: []types.PerfMetricId{{CounterId: 1, Instance: "*"}, {CounterId: 2, Instance: ""}},
+			want: []types.PerfMetricId{
+				{CounterId: 1, Instance: ""},
+				{CounterId: 1, Instance: "vmnic0"},
+				{CounterId: 1, Instance: "vmnic1"},
+				{CounterId: 2, Instance: ""},
+			},
+		},
+		{
+			name:      "instances are not repeated",
+			metricIds: []types.PerfMetricId{{CounterId: 1, Instance: "vmnic0"}, {CounterId: 1, Instance: "*"}},
+			want: []types.PerfMetricId{
+				{CounterId: 1, Instance: "vmnic0"},
+				{CounterId: 1, Instance: ""},
+				{CounterId: 1, Instance: "vmnic1"},
+			},
+		},
+		{
+			name:      "wildcard without available instances",
+			metricIds: []types.PerfMetricId{{CounterId: 3, Instance: "*"}},
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandWildcardInstances(tt.metricIds, available); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandWildcardInstances() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// an expanded wildcard is chunked by its instances, not as a single metric
+	ref := types.ManagedObjectReference{Type: "HostSystem", Value: "host-0"}
+	expanded := expandWildcardInstances([]types.PerfMetricId{{CounterId: 1, Instance: "*"}}, available)
+	chunks := chunkPerfQuerySpecs([]types.ManagedObjectReference{ref}, map[string][]types.PerfMetricId{ref.Value: expanded}, 2)
+	if len(chunks) != 2 || len(chunks[0][0].MetricId) != 2 || len(chunks[1][0].MetricId) != 1 {
+		t.Errorf("wildcard with 3 instances and limit 2 chunked as %v", chunks)
+	}
+}
+
+func TestMergeEntityMetric(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	info := func(offsets ...int) []types.PerfSampleInfo {
+		var infos []types.PerfSampleInfo
+		for _, offset := range offsets {
+			infos = append(infos, types.PerfSampleInfo{Timestamp: t0.Add(time.Duration(offset) * 20 * time.Second), Interval: 20})
+		}
+		return infos
+	}
+	entity := types.ManagedObjectReference{Type: "HostSystem", Value: "host-0"}
+
+	a := performance.EntityMetric{
+		Entity:     entity,
+		SampleInfo: info(0, 1),
+		Value:      []performance.MetricSeries{{Name: "mem.active.average", Value: []int64{10, 11}}},
+	}
+	b := performance.EntityMetric{
+		Entity:     entity,
+		SampleInfo: info(1, 2),
+		Value:      []performance.MetricSeries{{Name: "mem.granted.average", Value: []int64{21, 22}}},
+	}
+
+	merged := mergeEntityMetric(a, b)
+	if !reflect.DeepEqual(merged.SampleInfo, info(0, 1, 2)) {
+		t.Fatalf("SampleInfo = %v, want the union of both windows", merged.SampleInfo)
+	}
+	want := map[string][]int64{
+		"mem.active.average":  {10, 11, -1},
+		"mem.granted.average": {-1, 21, 22},
+	}
+	for _, s := range merged.Value {
+		if !reflect.DeepEqual(s.Value, want[s.Name]) {
+			t.Errorf("%s = %v, want %v", s.Name, s.Value, want[s.Name])
+		}
+	}
+}
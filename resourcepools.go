@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+)
+
+// allocation holds the printable values of a resource allocation, NA when unset
+type allocation struct {
+	reservation string
+	expandable  string
+	limit       string
+	shares      string
+	level       string
+}
+
+func allocationValues(a types.ResourceAllocationInfo) allocation {
+	values := allocation{reservation: "NA", expandable: "NA", limit: "NA", shares: "NA", level: "NA"}
+	if a.Reservation != nil {
+		values.reservation = fmt.Sprintf("%d", *a.Reservation)
+	}
+	if a.ExpandableReservation != nil {
+		values.expandable = fmt.Sprintf("%t", *a.ExpandableReservation)
+	}
+	if a.Limit != nil {
+		values.limit = fmt.Sprintf("%d", *a.Limit)
+	}
+	if a.Shares != nil {
+		values.shares = fmt.Sprintf("%d", a.Shares.Shares)
+		values.level = string(a.Shares.Level)
+	}
+	return values
+}
+
+// effectiveLimit returns the tighter of a pool limit and the limit inherited from its parent, -1 is unlimited
+func effectiveLimit(limit *int64, inherited int64) int64 {
+	if limit == nil || *limit < 0 {
+		return inherited
+	}
+	if inherited < 0 || *limit < inherited {
+		return *limit
+	}
+	return inherited
+}
+
+func GetClusterResourcePools(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"ClusterComputeResource"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var clusters []mo.ClusterComputeResource
+
+	err = v.RetrieveWithFilter(ctx, []string{"ClusterComputeResource"}, []string{"name", "resourcePool"}, &clusters, property.Match{"self.value": clusterFlag})
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(os.Stdout, "cluster;path;name;internalName;type;parent;depth;numChildren;numVms;cpuReservation;cpuExpandableReservation;cpuLimit;cpuEffectiveLimit;cpuShares;cpuSharesLevel;cpuReservationUsed;cpuUnreservedForPool;memoryReservation;memoryExpandableReservation;memoryLimit;memoryEffectiveLimit;memoryShares;memorySharesLevel;memoryReservationUsed;memoryUnreservedForPool\n")
+
+	clusterFound := false
+	pc := property.DefaultCollector(c)
+	for _, cluster := range clusters {
+		clusterFound = true
+		if cluster.ResourcePool == nil {
+			continue
+		}
+
+		var walk func(ref types.ManagedObjectReference, parent string, path string, depth int, cpuLimit int64, memoryLimit int64) error
+		walk = func(ref types.ManagedObjectReference, parent string, path string, depth int, cpuLimit int64, memoryLimit int64) error {
+			var rp mo.ResourcePool
+			err := pc.RetrieveOne(ctx, ref, []string{"name", "config", "runtime", "resourcePool", "vm"}, &rp)
+			if err != nil {
+				return err
+			}
+
+			if depth > 0 {
+				path = path + "/" + rp.Name
+			} else {
+				path = rp.Name
+			}
+			poolType := "resourcePool"
+			if ref.Type == "VirtualApp" {
+				poolType = "vApp"
+			}
+
+			cpuLimit = effectiveLimit(rp.Config.CpuAllocation.Limit, cpuLimit)
+			memoryLimit = effectiveLimit(rp.Config.MemoryAllocation.Limit, memoryLimit)
+			cpu := allocationValues(rp.Config.CpuAllocation)
+			memory := allocationValues(rp.Config.MemoryAllocation)
+
+			fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%d;%d;%d;%s;%s;%s;%d;%s;%s;%d;%d;%s;%s;%s;%d;%s;%s;%d;%d\n",
+				safeValue(cluster.Self.Value),
+				safeValue(path),
+				safeValue(rp.Name),
+				safeValue(rp.Self.Value),
+				poolType,
+				safeValue(parent),
+				depth,
+				len(rp.ResourcePool),
+				len(rp.Vm),
+				cpu.reservation,
+				cpu.expandable,
+				cpu.limit,
+				cpuLimit,
+				cpu.shares,
+				cpu.level,
+				rp.Runtime.Cpu.ReservationUsed,
+				rp.Runtime.Cpu.UnreservedForPool,
+				memory.reservation,
+				memory.expandable,
+				memory.limit,
+				memoryLimit,
+				memory.shares,
+				memory.level,
+				rp.Runtime.Memory.ReservationUsed,
+				rp.Runtime.Memory.UnreservedForPool,
+			)
+
+			for _, child := range rp.ResourcePool {
+				if err := walk(child, rp.Self.Value, path, depth+1, cpuLimit, memoryLimit); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if err := walk(*cluster.ResourcePool, "NA", "", 0, -1, -1); err != nil {
+			return err
+		}
+	}
+	if !clusterFound {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Cluster not found.")
+		os.Exit(1)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+	"strings"
+	"time"
+)
+
+func GetVMSnapshots(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"VirtualMachine"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var vms []mo.VirtualMachine
+
+	err = v.RetrieveWithFilter(ctx, []string{"VirtualMachine"}, []string{"summary", "snapshot", "layoutEx"}, &vms, property.Match{"name": vmFlag})
+
+	if err != nil {
+		return err
+	}
+	vmFound := false
+
+	fmt.Fprint(os.Stdout, "name;internalName;snapshot;snapshotInternalName;parent;description;createTime;ageSeconds;quiesced;powerState;current;sizeBytes\n")
+	for _, vm := range vms {
+		vmFound = true
+		if vm.Snapshot == nil {
+			continue
+		}
+		for _, tree := range vm.Snapshot.RootSnapshotList {
+			printSnapshotTree(vm, tree, nil)
+		}
+	}
+	if !vmFound {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "VM not found.")
+		os.Exit(1)
+	}
+	return nil
+}
+
+// printSnapshotTree prints a snapshot and walks its children recursively
+func printSnapshotTree(vm mo.VirtualMachine, tree types.VirtualMachineSnapshotTree, parent *types.VirtualMachineSnapshotTree) {
+	age := time.Since(tree.CreateTime)
+
+	if olderThanFlag == 0 || age > olderThanFlag {
+		var parentRef *types.ManagedObjectReference
+		parentName := "-"
+		if parent != nil {
+			parentRef = &parent.Snapshot
+			parentName = parent.Name
+		}
+
+		current := vm.Snapshot.CurrentSnapshot != nil && vm.Snapshot.CurrentSnapshot.Value == tree.Snapshot.Value
+
+		size := 0
+		if vm.LayoutEx != nil {
+			size = object.SnapshotSize(tree.Snapshot, parentRef, vm.LayoutEx, current)
+		}
+
+		fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%s;%d;%v;%s;%v;%d\n",
+			safeValue(vm.Summary.Config.Name),
+			safeValue(vm.Summary.Vm.Value),
+			safeValue(tree.Name),
+			safeValue(tree.Snapshot.Value),
+			safeValue(parentName),
+			safeValue(strings.ReplaceAll(tree.Description, "\n", " ")),
+			safeValue(&tree.CreateTime),
+			safeValue(int64(age.Seconds())),
+			safeValue(tree.Quiesced),
+			safeValue(tree.State),
+			safeValue(current),
+			safeValue(size),
+		)
+	}
+
+	for _, child := range tree.ChildSnapshotList {
+		printSnapshotTree(vm, child, &tree)
+	}
+}
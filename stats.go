@@ -11,7 +11,8 @@ import (
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 	"os"
-	"regexp"
+	"path"
+	"sort"
 	"strings"
 )
 
@@ -38,7 +39,7 @@ func GetHostStats(ctx context.Context, c *vim25.Client, functions []string) erro
 		hostNames = append(hostNames, hs.Summary.Host.Value)
 		internalHostnames[hs.Summary.Host.Value] = hs.Summary.Config.Name
 	}
-	return getStats(ctx, err, v, functions, "HostSystem", hostNames, internalHostnames, hostFlag)
+	return getStats(ctx, err, c, functions, "HostSystem", hostNames, internalHostnames, hostFlag)
 
 }
 
@@ -64,7 +65,7 @@ func GetVMStats(ctx context.Context, c *vim25.Client, functions []string) error
 		vmNames = append(vmNames, vm.Summary.Vm.Value)
 		internalVMNames[vm.Summary.Vm.Value] = vm.Summary.Config.Name
 	}
-	return getStats(ctx, err, v, functions, "VirtualMachine", vmNames, internalVMNames, vmFlag)
+	return getStats(ctx, err, c, functions, "VirtualMachine", vmNames, internalVMNames, vmFlag)
 
 }
 
@@ -90,125 +91,202 @@ func GetResourcePoolStats(ctx context.Context, c *vim25.Client, functions []stri
 		rpNames = append(rpNames, r.Self.Value)
 		internalRPNames[r.Self.Value] = r.Self.Value
 	}
-	return getStats(ctx, err, v, functions, "ResourcePool", rpNames, internalRPNames, resourcePoolFlag)
+	return getStats(ctx, err, c, functions, "ResourcePool", rpNames, internalRPNames, resourcePoolFlag)
 
 }
 
-func GetClusterStats(ctx context.Context, c *vim25.Client, functions []string) error {
+func GetVAppStats(ctx context.Context, c *vim25.Client, functions []string) error {
 	m := view.NewManager(c)
-	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"ClusterComputeResource"}, true)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"VirtualApp"}, true)
 	if err != nil {
 		return err
 	}
 	defer v.Destroy(ctx)
 
-	var cr []mo.ClusterComputeResource
-	err = v.RetrieveWithFilter(ctx, []string{"ClusterComputeResource"}, []string{"summary"}, &cr, property.Match{"self.value": clusterFlag})
+	var vapps []mo.VirtualApp
+	err = v.RetrieveWithFilter(ctx, []string{"VirtualApp"}, []string{"name"}, &vapps, property.Match{"name": vappFlag})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting cluster name: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Error getting vApp name: %s\n", err)
 		os.Exit(1)
 	}
-	var crNames []string
-	var internalCRNames = make(map[string]string)
-	// Iterate over the host systems and collect names
-	for _, c := range cr {
+	var vappNames []string
+	var internalVAppNames = make(map[string]string)
+	for _, vapp := range vapps {
 
-		crNames = append(crNames, c.Self.Value)
-		internalCRNames[c.Self.Value] = c.Self.Value
+		vappNames = append(vappNames, vapp.Self.Value)
+		internalVAppNames[vapp.Self.Value] = vapp.Name
 	}
-	return getStats(ctx, err, v, functions, "ClusterComputeResource", crNames, internalCRNames, clusterFlag)
+	return getStats(ctx, err, c, functions, "VirtualApp", vappNames, internalVAppNames, vappFlag)
 
 }
 
-func GetDatastoreStats(ctx context.Context, c *vim25.Client, functions []string) error {
+func GetDatastoreClusterStats(ctx context.Context, c *vim25.Client, functions []string) error {
 	m := view.NewManager(c)
-
-	var err error
-	var hostNames []string
-	if hostFlag != "*" {
-		hostNames, err = getHostNames(ctx, c, mountedOnFlag)
-	}
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"StoragePod"}, true)
 	if err != nil {
 		return err
 	}
+	defer v.Destroy(ctx)
 
-	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"Datastore"}, true)
+	var pods []mo.StoragePod
+	err = v.RetrieveWithFilter(ctx, []string{"StoragePod"}, []string{"name"}, &pods, property.Match{"name": datastoreClusterFlag})
 	if err != nil {
-		return err
+		fmt.Fprintf(os.Stderr, "Error getting datastore cluster name: %s\n", err)
+		os.Exit(1)
 	}
-	defer v.Destroy(ctx)
+	var podNames []string
+	var internalPodNames = make(map[string]string)
+	for _, pod := range pods {
 
-	// Destination slice to hold the result
-	var datastores []mo.Datastore
+		podNames = append(podNames, pod.Self.Value)
+		internalPodNames[pod.Self.Value] = pod.Name
+	}
+	return getStats(ctx, err, c, functions, "StoragePod", podNames, internalPodNames, datastoreClusterFlag)
+
+}
 
-	// Retrieve datastores the match the filter
-	err = v.RetrieveWithFilter(ctx, []string{"Datastore"}, []string{"summary", "host", "info", "vm"}, &datastores, property.Match{"name": datastoreFlag})
+func GetClusterStats(ctx context.Context, c *vim25.Client, functions []string) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"ClusterComputeResource"}, true)
 	if err != nil {
 		return err
 	}
+	defer v.Destroy(ctx)
 
-	datastoreFound := false
-	// Iterate over the filtered datastores
-	for _, ds := range datastores {
-		var internalHostValues []string
-		for _, host := range ds.Host {
-			internalHostValues = append(internalHostValues, host.Key.Value)
-		}
+	var cr []mo.ClusterComputeResource
+	err = v.RetrieveWithFilter(ctx, []string{"ClusterComputeResource"}, []string{"summary"}, &cr, property.Match{"self.value": clusterFlag})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting cluster name: %s\n", err)
+		os.Exit(1)
+	}
+	var crNames []string
+	var internalCRNames = make(map[string]string)
+	// Iterate over the host systems and collect names
+	for _, c := range cr {
 
-		if hostFlag != "*" {
-			if !containsAny(hostNames, internalHostValues) {
-				continue
-			}
-		}
+		crNames = append(crNames, c.Self.Value)
+		internalCRNames[c.Self.Value] = c.Self.Value
+	}
+	return getStats(ctx, err, c, functions, "ClusterComputeResource", crNames, internalCRNames, clusterFlag)
 
-		datastoreFound = true
+}
+
+func GetDatastoreStats(ctx context.Context, c *vim25.Client, functions []string) error {
+	datastores, err := getDatastores(ctx, c, []string{"summary", "host"})
+	if err != nil {
+		return err
 	}
-	if !datastoreFound {
+	if len(datastores) == 0 {
 		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Datastore not found.")
 		os.Exit(1)
 	}
+
 	var dsNames []string
 	var internalDSNames = make(map[string]string)
-	// Iterate over the host systems and collect names
+	// Iterate over the selected datastores and collect names
 	for _, d := range datastores {
 		dsNames = append(dsNames, d.Self.Value)
-		internalDSNames[d.Self.Value] = d.Self.Value
+		internalDSNames[d.Self.Value] = d.Summary.Name
 	}
-	return getStats(ctx, err, v, functions, "Datastore", dsNames, internalDSNames, datastoreFlag)
+	return getStats(ctx, err, c, functions, "Datastore", dsNames, internalDSNames, datastoreFlag)
 }
 
-func getStats(ctx context.Context, err error, v *view.ContainerView, functions []string, entityToQuery string, names []string, internalNames map[string]string, flag string) error {
-	var metricsToQuery []string
-
-	if len(strings.Split(metricsFlag, ",")) > 1 {
-		metricsToQuery = strings.Split(metricsFlag, ",")
-	} else {
-		metricsToQuery = []string{metricsFlag}
+// metricSelector is a metric name with the instances to query, from the metric[inst1,inst2] syntax
+type metricSelector struct {
+	name      string
+	instances []string
+}
 
+// parseMetricSelectors splits the metrics flag on commas outside brackets, metrics without
+// an instance selector use the default instance
+func parseMetricSelectors(metrics string, defaultInstance string) ([]metricSelector, error) {
+	var selectors []metricSelector
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range metrics {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, metrics[start:i])
+				start = i + 1
+			}
+		}
+		if depth < 0 || depth > 1 {
+			return nil, fmt.Errorf("invalid metric selector %s", metrics)
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("invalid metric selector %s", metrics)
 	}
+	parts = append(parts, metrics[start:])
 
-	// construct titles
-	title := ""
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		selector := metricSelector{name: part, instances: []string{defaultInstance}}
+		if i := strings.Index(part, "["); i >= 0 {
+			if !strings.HasSuffix(part, "]") {
+				return nil, fmt.Errorf("invalid metric selector %s", part)
+			}
+			selector.name = part[:i]
+			selector.instances = nil
+			for _, instance := range strings.Split(part[i+1:len(part)-1], ",") {
+				selector.instances = append(selector.instances, strings.TrimSpace(instance))
+			}
+		}
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
 
-	for range metricsToQuery {
-		title += "entity;name;internalName;instance;metric"
-		for _, function := range functions {
-			title += ";" + function
+// matchesInstance reports whether a returned series instance was requested by the selector
+func (s metricSelector) matchesInstance(instance string) bool {
+	for _, requested := range s.instances {
+		if requested == "*" || requested == instance {
+			return true
 		}
-		title += ";units|"
 	}
-	// delete the las pipe character
-	title = title[:len(title)-1]
+	return false
+}
 
-	fmt.Println(title)
+// getStats queries the metrics of the selected entity names, flag is the user selection shown in messages
+func getStats(ctx context.Context, err error, c *vim25.Client, functions []string, entityToQuery string, names []string, internalNames map[string]string, flag string) error {
+	if listInstancesFlag {
+		return listInstances(ctx, c, entityToQuery, names, internalNames)
+	}
 
-	entityRefs, err := v.Find(ctx, []string{entityToQuery}, nil)
+	selectors, err := parseMetricSelectors(metricsFlag, instanceFlag)
 	if err != nil {
 		return err
 	}
+	err = checkDerivedEntityType(selectors, entityToQuery)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	// one row per entity, metric and instance
+	title := "entity;name;internalName;instance;metric"
+	for _, function := range functions {
+		title += ";" + function
+	}
+	title += ";units"
+	fmt.Println(title)
+
+	// only the selected entities are queried
+	var entityRefs []types.ManagedObjectReference
+	for _, name := range names {
+		entityRefs = append(entityRefs, types.ManagedObjectReference{Type: entityToQuery, Value: name})
+	}
 
 	// Create a PerfManager
-	perfManager := performance.NewManager(v.Client())
+	perfManager := performance.NewManager(c)
 
 	// Retrieve counters name list
 	counters, err := perfManager.CounterInfoByName(ctx)
@@ -217,98 +295,118 @@ func getStats(ctx context.Context, err error, v *view.ContainerView, functions [
 		os.Exit(1)
 	}
 
-	// Check if the metrics to query exist
+	// Check if the metrics to query exist, derived metrics are checked through their inputs
+	var metricsToQuery []string
+	for _, selector := range selectors {
+		metricsToQuery = append(metricsToQuery, metricInputs(selector.name)...)
+	}
 	err = checkMetricExistence(counters, metricsToQuery)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, err.Error())
 		os.Exit(1)
 	}
-	// Create PerfQuerySpec
-	spec := types.PerfQuerySpec{
-		MaxSample:  int32(maxSamplesFlag),
-		MetricId:   []types.PerfMetricId{{Instance: instanceFlag}},
-		IntervalId: int32(intervalFlag),
+
+	// inputs shared by several metrics are only queried once
+	var metricIds []types.PerfMetricId
+	queried := make(map[types.PerfMetricId]bool)
+	derivedNeeded := false
+	for _, selector := range selectors {
+		if _, ok := derivedMetrics[selector.name]; ok {
+			derivedNeeded = true
+		}
+		for _, input := range metricInputs(selector.name) {
+			for _, instance := range selector.instances {
+				id := types.PerfMetricId{CounterId: counters[input].Key, Instance: instance}
+				if !queried[id] {
+					queried[id] = true
+					metricIds = append(metricIds, id)
+				}
+			}
+		}
 	}
 
-	// Query metrics
-	sample, err := perfManager.SampleByName(ctx, spec, metricsToQuery, entityRefs)
-	if err != nil {
+	// CPU percentages and network utilization need the CPU count and nic link speeds of the entities
+	derivedEntities := make(map[string]derivedEntity)
+	if derivedNeeded && len(entityRefs) > 0 {
+		derivedEntities, err = getDerivedEntities(ctx, c, entityToQuery, entityRefs)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting metric: %s\n", err)
-			os.Exit(1)
+			return err
 		}
 	}
 
-	result, err := perfManager.ToMetricSeries(ctx, sample)
+	// Query metrics in chunks within the vCenter query size limit
+	result, err := queryPerf(ctx, perfManager, entityRefs, metricIds, getMaxQueryMetrics(ctx, c))
 	if err != nil {
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting metric series: %s\n", err)
-			os.Exit(1)
-		}
+		fmt.Fprintf(os.Stderr, "Error getting metric: %s\n", err)
+		os.Exit(1)
 	}
 
 	// Read result
-	var results []string
+	metricFound := false
 	for _, metric := range result {
-		resultLine := ""
 		name := metric.Entity
-		if flag != "*" && !contains(names, name.Value) {
-			continue
-		}
-		for _, v := range metric.Value {
-			counter := counters[v.Name]
-			units := counter.UnitInfo.GetElementDescription().Label
 
-			instance := v.Instance
-			if instance == "" {
-				instance = "-"
+		// rows follow the order of the requested metrics, then the instance name
+		for _, selector := range selectors {
+			var rows []statsRow
+			if derived, ok := derivedMetrics[selector.name]; ok {
+				rows = derivedRows(derived, selector, metric, derivedEntities[name.Value])
+			} else {
+				for _, s := range metric.Value {
+					if s.Name != selector.name || !selector.matchesInstance(s.Instance) {
+						continue
+					}
+					// unavailable samples are reported as -1
+					var available []int64
+					for _, value := range s.Value {
+						if value >= 0 {
+							available = append(available, value)
+						}
+					}
+					if len(available) == 0 {
+						continue
+					}
+					s.Value = available
+					values, err := parseCSV(s.ValueCSV())
+					if err != nil {
+						fmt.Fprint(os.Stderr, "Error parsing metric CSV values: ", err, "\n")
+						os.Exit(1)
+					}
+					unit := counters[s.Name].UnitInfo.GetElementDescription()
+					rows = append(rows, statsRow{instance: s.Instance, values: values, unit: unit.Label, unitKey: unit.Key})
+				}
+				sort.Slice(rows, func(i, j int) bool { return rows[i].instance < rows[j].instance })
 			}
 
-			if len(v.Value) != 0 {
-				values, err := parseCSV(v.ValueCSV())
-				if err != nil {
-					fmt.Fprint(os.Stderr, "Error parsing metric CSV values: ", err, "\n")
-					os.Exit(1)
+			for _, row := range rows {
+				if normalizeFlag {
+					row = normalizeRow(row)
+				}
+				instance := row.instance
+				if instance == "" {
+					instance = "-"
 				}
-				//fmt.Printf("entity=%s;name=%s;instance=%s;metric=%s",
-				//	name.Type, name.Value, instance, v.Name)
-				resultLine += fmt.Sprintf("%s;%s;%s;%s;%s",
-					name.Type, internalNames[name.Value], name.Value, instance, v.Name)
 
+				resultLine := fmt.Sprintf("%s;%s;%s;%s;%s",
+					name.Type, internalNames[name.Value], name.Value, instance, selector.name)
 				for _, function := range functions {
-					result, err := applyFunction(values, function)
+					result, err := applyFunction(row.values, function)
 					if err != nil {
 						fmt.Fprint(os.Stderr, "Error applying function:", err, "\n")
 						os.Exit(1)
 					}
-					resultLine += fmt.Sprintf(";%.2f", result)
-				}
-				resultLine += fmt.Sprintf(";%s;", units)
-				if instanceFlag != "" {
-					resultLine += "\n"
-				} else {
-					resultLine += "|"
+					if normalizeFlag {
+						resultLine += ";" + formatNormalized(result)
+					} else {
+						resultLine += fmt.Sprintf(";%.2f", result)
+					}
 				}
+				resultLine += fmt.Sprintf(";%s", row.unit)
 
-			} else {
-				fmt.Fprintf(os.Stderr, "No values found for metric %s\n", v.Name)
-				os.Exit(1)
+				fmt.Println(resultLine)
+				metricFound = true
 			}
-
 		}
-		// delete last semicolon character
-		resultLine = resultLine[:len(resultLine)-1]
-
-		results = append(results, resultLine)
-
-	}
-	// print title and then results
-
-	metricFound := false
-	for _, result := range results {
-
-		metricFound = true
-		fmt.Println(result)
 	}
 	if !metricFound {
 		fmt.Fprintf(os.Stderr, "\nMetric not found for entity %s\n", flag)
@@ -326,118 +424,53 @@ func checkMetricExistence(counterMap map[string]*types.PerfCounterInfo, metricNa
 	return nil
 }
 
-func ListMetrics(ctx context.Context, client *vim25.Client) error {
-	// Create a view manager
-	viewMgr := view.NewManager(client)
-
-	// Create a view for various types of entities (VirtualMachine, HostSystem, Datastore, etc.)
-	v, err := viewMgr.CreateContainerView(ctx, client.ServiceContent.RootFolder, []string{"VirtualMachine", "HostSystem", "Datastore", "ClusterComputeResource"}, true)
+// listInstances prints every counter and instance pair available for the selected entities
+func listInstances(ctx context.Context, c *vim25.Client, entityToQuery string, names []string, internalNames map[string]string) error {
+	perfManager := performance.NewManager(c)
+	counters, err := perfManager.CounterInfoByKey(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create container view: %v", err)
-	}
-	defer v.Destroy(ctx)
-
-	// Retrieve the list of performance metrics
-	perfMgr := performance.NewManager(client)
-
-	// Fetch all available performance counters
-	counters, err := perfMgr.CounterInfoByName(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get counter info: %v", err)
-	}
-
-	// Retrieve the entities to determine the available types
-	var entities []mo.ManagedEntity
-	err = v.Retrieve(ctx, []string{"VirtualMachine", "HostSystem", "Datastore", "ClusterComputeResource"}, nil, &entities)
-	if err != nil {
-		return fmt.Errorf("failed to retrieve entities: %v", err)
+		return err
 	}
 
-	// Create a map to track which counters are applicable to which entity types
-	entityMetrics := make(map[string]map[string]bool)
+	fmt.Println("entity;name;internalName;metric;counterKey;instance")
 
-	// Populate the map with entity types and their valid metrics
-	for _, entity := range entities {
-		// Query available metrics for this entity
-		metricsList, err := perfMgr.AvailableMetric(ctx, entity.Reference(), 300) // Using 300 seconds as a general interval
+	instanceFound := false
+	for _, name := range names {
+		ref := types.ManagedObjectReference{Type: entityToQuery, Value: name}
+		metrics, err := perfManager.AvailableMetric(ctx, ref, int32(intervalFlag))
 		if err != nil {
-			continue
+			return err
 		}
-
-		if _, exists := entityMetrics[entity.Self.Type]; !exists {
-			entityMetrics[entity.Self.Type] = make(map[string]bool)
-		}
-
-		for _, metric := range metricsList {
-			counterKey := fmt.Sprintf("%d", metric.CounterId)
-			entityMetrics[entity.Self.Type][counterKey] = true
-		}
-	}
-
-	// Compile a regex if metricsFlag contains a wildcard
-	var metricRegex *regexp.Regexp
-	if metricsFlag != "" && metricsFlag != "*" {
-		pattern := strings.ReplaceAll(metricsFlag, "*", ".*")
-		metricRegex, err = regexp.Compile("^" + pattern + "$")
-		if err != nil {
-			return fmt.Errorf("failed to compile regex for metricsFlag: %v", err)
-		}
-	}
-
-	// Print out the performance counters, their intervals, and the types of entities they are valid for
-	fmt.Println("Performance Counters, Real-Time Availability, and Valid Entity Types:")
-	for counterName, counterInfo := range counters {
-		// Filter based on metricsFlag
-		if metricRegex != nil && !metricRegex.MatchString(counterName) {
-			continue
-		}
-
-		fmt.Printf("Counter: %s (%d)\n", counterName, counterInfo.Key)
-
-		// Print the entity types valid for this counter
-		fmt.Println("Valid for entity types:")
-		for entityType := range entityMetrics {
-			if entityMetrics[entityType][fmt.Sprintf("%d", counterInfo.Key)] {
-				fmt.Printf("- Entity Type: %s\n", entityType)
+		sort.Slice(metrics, func(i, j int) bool {
+			if metrics[i].CounterId != metrics[j].CounterId {
+				return metrics[i].CounterId < metrics[j].CounterId
 			}
-		}
+			return metrics[i].Instance < metrics[j].Instance
+		})
 
-		// Check if the counter is available for real-time interval for any entity type
-		isRealTimeAvailable := false
-		for _, entity := range entities {
-			perfQuerySpec := types.PerfQuerySpec{
-				Entity:     entity.Reference(),
-				MaxSample:  1,
-				MetricId:   []types.PerfMetricId{{CounterId: counterInfo.Key, Instance: "*"}},
-				IntervalId: 20, // Real-time interval
+		for _, metric := range metrics {
+			counter, ok := counters[metric.CounterId]
+			if !ok {
+				continue
 			}
-
-			sample, err := perfMgr.SampleByName(ctx, perfQuerySpec, []string{counterName}, []types.ManagedObjectReference{entity.Reference()})
-			if err == nil && len(sample) > 0 {
-				isRealTimeAvailable = true
-				break
+			if metricsFlag != "" {
+				if matched, _ := path.Match(metricsFlag, counter.Name()); !matched {
+					continue
+				}
 			}
-		}
 
-		// Print if real-time interval is available
-		if isRealTimeAvailable {
-			fmt.Println("Real-Time Interval Available: Yes")
-		} else {
-			fmt.Println("Real-Time Interval Available: No")
+			instance := metric.Instance
+			if instance == "" {
+				instance = "-"
+			}
+			fmt.Printf("%s;%s;%s;%s;%d;%s\n",
+				entityToQuery, internalNames[name], name, counter.Name(), metric.CounterId, instance)
+			instanceFound = true
 		}
-		fmt.Println()
 	}
-
-	// Print standard available intervals for all counters
-	fmt.Println("Standard Available Intervals for All Counters:")
-	intervals, err := perfMgr.HistoricalInterval(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get historical intervals: %v", err)
-	}
-	for _, interval := range intervals {
-		fmt.Printf("- IntervalId: %d, Name: %s, Sampling Period: %d seconds, Retention Length: %d\n",
-			interval.SamplingPeriod, interval.Name, interval.SamplingPeriod, interval.Length)
+	if !instanceFound {
+		fmt.Fprintf(os.Stderr, "\nNo instances found for interval %d\n", intervalFlag)
+		os.Exit(1)
 	}
-
 	return nil
 }
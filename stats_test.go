@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMetricSelectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics string
+		want    []metricSelector
+		wantErr bool
+	}{
+		{
+			name:    "default instance",
+			metrics: "cpu.usage.average",
+			want:    []metricSelector{{name: "cpu.usage.average", instances: []string{""}}},
+		},
+		{
+			name:    "several metrics with instances",
+			metrics: "cpu.usage.average, net.usage.average[vmnic0, vmnic1],cpu.ready.summation[*]",
+			want: []metricSelector{
+				{name: "cpu.usage.average", instances: []string{""}},
+				{name: "net.usage.average", instances: []string{"vmnic0", "vmnic1"}},
+				{name: "cpu.ready.summation", instances: []string{"*"}},
+			},
+		},
+		{
+			name:    "empty parts are skipped",
+			metrics: "cpu.usage.average,,",
+			want:    []metricSelector{{name: "cpu.usage.average", instances: []string{""}}},
+		},
+		{
+			name:    "empty",
+			metrics: "",
+			want:    nil,
+		},
+		{name: "nested bracket", metrics: "net.usage.average[[vmnic0]]", wantErr: true},
+		{name: "unterminated bracket", metrics: "net.usage.average[vmnic0,cpu.usage.average", wantErr: true},
+		{name: "unopened bracket", metrics: "net.usage.average]vmnic0", wantErr: true},
+		{name: "text after bracket", metrics: "net.usage.average[vmnic0]x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMetricSelectors(tt.metrics, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMetricSelectors(%q) error = %v, wantErr %v", tt.metrics, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMetricSelectors(%q) = %v, want %v", tt.metrics, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetricSelectorMatchesInstance(t *testing.T) {
+	tests := []struct {
+		instances []string
+		instance  string
+		want      bool
+	}{
+		{instances: []string{""}, instance: "", want: true},
+		{instances: []string{""}, instance: "vmnic0", want: false},
+		{instances: []string{"*"}, instance: "vmnic0", want: true},
+		{instances: []string{"vmnic0", "vmnic1"}, instance: "vmnic1", want: true},
+	}
+
+	for _, tt := range tests {
+		s := metricSelector{name: "net.usage.average", instances: tt.instances}
+		if got := s.matchesInstance(tt.instance); got != tt.want {
+			t.Errorf("matchesInstance(%q) with %v = %v, want %v", tt.instance, tt.instances, got, tt.want)
+		}
+	}
+}
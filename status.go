@@ -3,10 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
+	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/view"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
 	"os"
 	"strings"
 )
@@ -147,52 +149,26 @@ func GetVMStatus(ctx context.Context, c *vim25.Client) error {
 }
 
 func GetDatastoreStatus(ctx context.Context, c *vim25.Client) error {
-
-	// getHostNames using hostedByFlag
-	//var hostName string
-	var err error
-	var hostNames []string
-	if hostFlag != "*" {
-		hostNames, err = getHostNames(ctx, c, mountedOnFlag)
-	}
-
+	hostNames, err := getEntityNames(ctx, c, "HostSystem")
 	if err != nil {
-		showHostStatusError("DATASTORE_NOT_FOUND")
+		showDatastoreStatusError(err.Error())
 	}
 
-	m := view.NewManager(c)
-
-	vDatastore, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"Datastore"}, true)
+	dss, err := getDatastores(ctx, c, []string{"summary", "host"})
 	if err != nil {
 		showDatastoreStatusError(err.Error())
 	}
-	defer vDatastore.Destroy(ctx)
-
-	fmt.Fprint(os.Stdout, "name;type;maintenanceMode;capacity;freeSpace;uncommitted;accessible;mountedOn;mountedOnInternal;proxyStatus\n")
-
-	// Destination slice to hold the result
-	var dss []mo.Datastore
+	if len(dss) == 0 {
+		showDatastoreStatusError("DATASTORE_NOT_FOUND")
+	}
 
-	// Retrieve datastores the match the filter
-	err = vDatastore.RetrieveWithFilter(ctx, []string{"Datastore"}, []string{"summary", "host", "info", "vm"}, &dss, property.Match{"name": datastoreFlag})
+	fmt.Fprint(os.Stdout, datastoreStatusHeader)
 
-	if err != nil {
-		showDatastoreStatusError(err.Error())
-	}
-	datastoreFound := false
-	// Iterate over the filtered datastores
 	for _, ds := range dss {
-		// If the datastore is not hosted by the host, skip it
-		var internalHostValues []string
-		for _, host := range ds.Host {
-			internalHostValues = append(internalHostValues, host.Key.Value)
-		}
-		if hostFlag != "*" {
-			if !containsAny(hostNames, internalHostValues) {
-				continue
-			}
-		}
-		fmt.Fprintf(os.Stdout, "%s;%s;%v;%v;%v;%v;%v;%s;%s;%s\n",
+		mountedOn, mountedOnInternal, inaccessibleOn, _ := datastoreMounts(ds, hostNames)
+
+		provisioned, overcommitRatio, percentUsed, percentProvisioned := datastoreUsage(ds.Summary.Capacity, ds.Summary.FreeSpace, ds.Summary.Uncommitted)
+		fmt.Fprintf(os.Stdout, "%s;%s;%v;%v;%v;%v;%v;%s;%s;%d;%.2f;%.2f;%.2f;%s;%s\n",
 			safeValue(ds.Summary.Name),
 			safeValue(ds.Summary.Type),
 			safeValue(ds.Summary.MaintenanceMode),
@@ -200,14 +176,14 @@ func GetDatastoreStatus(ctx context.Context, c *vim25.Client) error {
 			safeValue(ds.Summary.FreeSpace),
 			safeValue(ds.Summary.Uncommitted),
 			safeValue(ds.Summary.Accessible),
-			safeValue(mountedOnFlag),
-			safeValue(strings.Join(internalHostValues, ",")),
+			safeValue(strings.Join(mountedOn, ",")),
+			safeValue(strings.Join(mountedOnInternal, ",")),
+			provisioned,
+			overcommitRatio,
+			percentUsed,
+			percentProvisioned,
+			safeValue(strings.Join(inaccessibleOn, ",")),
 			"OK")
-
-		datastoreFound = true
-	}
-	if !datastoreFound {
-		showDatastoreStatusError("DATASTORE_NOT_FOUND")
 	}
 	return nil
 }
@@ -231,7 +207,7 @@ func GetResourcePoolStatus(ctx context.Context, c *vim25.Client) error {
 	err = vResource.RetrieveWithFilter(ctx, []string{"ResourcePool"}, []string{"parent", "namespace", "name", "summary", "owner", "config", "vm", "runtime"}, &rp, property.Match{"self.value": resourcePoolFlag})
 
 	if err != nil {
-		showDatastoreStatusError("RESOURCE_POOL_NOT_FOUND")
+		showResourcePoolStatusError("RESOURCE_POOL_NOT_FOUND")
 	}
 
 	resourceFound := false
@@ -271,3 +247,177 @@ func GetResourcePoolStatus(ctx context.Context, c *vim25.Client) error {
 	}
 	return nil
 }
+
+func GetDVSStatus(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"DistributedVirtualSwitch"}, true)
+	if err != nil {
+		showDVSStatusError(err.Error())
+	}
+	defer v.Destroy(ctx)
+	var dvss []mo.DistributedVirtualSwitch
+
+	err = v.RetrieveWithFilter(ctx, []string{"DistributedVirtualSwitch"}, []string{"name", "summary", "config", "overallStatus"}, &dvss, property.Match{"name": dvsFlag})
+
+	if err != nil {
+		showDVSStatusError("DVS_NOT_FOUND")
+	}
+
+	dvsFound := false
+	connected := true
+
+	fmt.Fprint(os.Stdout, "name;internalName;numPorts;portsInUse;numHosts;hostsNotUp;overallStatus;proxyStatus\n")
+	for _, dvs := range dvss {
+		// fetching the ports is expensive on large switches, so ports in use are only
+		// counted when --dvs selects a single switch
+		portsInUse := "NA"
+		if len(dvss) == 1 {
+			ports, err := object.NewDistributedVirtualSwitch(c, dvs.Self).FetchDVPorts(ctx, &types.DistributedVirtualSwitchPortCriteria{Connected: &connected})
+			if err != nil {
+				showDVSStatusError(err.Error())
+			}
+			portsInUse = fmt.Sprintf("%d", len(ports))
+		}
+
+		// config is not returned when access to the switch is restricted
+		numHosts, hostsNotUp := "NA", "NA"
+		if dvs.Config != nil {
+			members := dvs.Config.GetDVSConfigInfo().Host
+			notUp := 0
+			for _, member := range members {
+				if member.Status != "up" {
+					notUp++
+				}
+			}
+			numHosts = fmt.Sprintf("%d", len(members))
+			hostsNotUp = fmt.Sprintf("%d", notUp)
+		}
+
+		fmt.Fprintf(os.Stdout, "%s;%s;%d;%s;%s;%s;%s;%s\n",
+			safeValue(dvs.Name),
+			safeValue(dvs.Self.Value),
+			safeValue(dvs.Summary.NumPorts),
+			safeValue(portsInUse),
+			safeValue(numHosts),
+			safeValue(hostsNotUp),
+			safeValue(dvs.OverallStatus),
+			"OK")
+
+		dvsFound = true
+	}
+	if !dvsFound {
+		showDVSStatusError("DVS_NOT_FOUND")
+	}
+	return nil
+}
+
+func GetPortgroupStatus(ctx context.Context, c *vim25.Client) error {
+	dvsNames, err := getEntityNames(ctx, c, "DistributedVirtualSwitch")
+	if err != nil {
+		showPortgroupStatusError(err.Error())
+	}
+
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"DistributedVirtualPortgroup"}, true)
+	if err != nil {
+		showPortgroupStatusError(err.Error())
+	}
+	defer v.Destroy(ctx)
+	var pgs []mo.DistributedVirtualPortgroup
+
+	err = v.RetrieveWithFilter(ctx, []string{"DistributedVirtualPortgroup"}, []string{"name", "key", "config", "overallStatus"}, &pgs, property.Match{"name": portgroupFlag})
+
+	if err != nil {
+		showPortgroupStatusError("PORTGROUP_NOT_FOUND")
+	}
+
+	portgroupFound := false
+	connected := true
+
+	fmt.Fprint(os.Stdout, "name;internalName;dvs;numPorts;portsInUse;overallStatus;proxyStatus\n")
+	for _, pg := range pgs {
+		dvsName := "NA"
+		portsInUse := 0
+		if pg.Config.DistributedVirtualSwitch != nil {
+			dvsName = dvsNames[pg.Config.DistributedVirtualSwitch.Value]
+			ports, err := object.NewDistributedVirtualSwitch(c, *pg.Config.DistributedVirtualSwitch).FetchDVPorts(ctx, &types.DistributedVirtualSwitchPortCriteria{Connected: &connected, PortgroupKey: []string{pg.Key}})
+			if err != nil {
+				showPortgroupStatusError(err.Error())
+			}
+			portsInUse = len(ports)
+		}
+
+		fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%d;%s;%s\n",
+			safeValue(pg.Name),
+			safeValue(pg.Self.Value),
+			safeValue(dvsName),
+			safeValue(pg.Config.NumPorts),
+			portsInUse,
+			safeValue(pg.OverallStatus),
+			"OK")
+
+		portgroupFound = true
+	}
+	if !portgroupFound {
+		showPortgroupStatusError("PORTGROUP_NOT_FOUND")
+	}
+	return nil
+}
+
+func GetDatastoreClusterStatus(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"StoragePod"}, true)
+	if err != nil {
+		showDatastoreClusterStatusError(err.Error())
+	}
+	defer v.Destroy(ctx)
+
+	var pods []mo.StoragePod
+
+	err = v.RetrieveWithFilter(ctx, []string{"StoragePod"}, []string{"name", "summary", "childEntity", "overallStatus"}, &pods, property.Match{"name": datastoreClusterFlag})
+	if err != nil {
+		showDatastoreClusterStatusError("DATASTORE_CLUSTER_NOT_FOUND")
+	}
+
+	podFound := false
+
+	fmt.Fprint(os.Stdout, "name;internalName;numDatastores;capacity;freeSpace;uncommitted;provisioned;overcommitRatio;percentUsed;percentProvisioned;overallStatus;proxyStatus\n")
+	for _, pod := range pods {
+		// aggregate the figures of the member datastores
+		var dss []mo.Datastore
+		var capacity, freeSpace, uncommitted int64
+		if len(pod.ChildEntity) > 0 {
+			err = property.DefaultCollector(c).Retrieve(ctx, pod.ChildEntity, []string{"summary"}, &dss)
+			if err != nil {
+				showDatastoreClusterStatusError(err.Error())
+			}
+		}
+		for _, ds := range dss {
+			capacity += ds.Summary.Capacity
+			freeSpace += ds.Summary.FreeSpace
+			uncommitted += ds.Summary.Uncommitted
+		}
+
+		provisioned, overcommitRatio, percentUsed, percentProvisioned := datastoreUsage(capacity, freeSpace, uncommitted)
+		fmt.Fprintf(os.Stdout, "%s;%s;%d;%d;%d;%d;%d;%.2f;%.2f;%.2f;%s;%s\n",
+			safeValue(pod.Name),
+			safeValue(pod.Self.Value),
+			len(dss),
+			capacity,
+			freeSpace,
+			uncommitted,
+			provisioned,
+			overcommitRatio,
+			percentUsed,
+			percentProvisioned,
+			safeValue(pod.OverallStatus),
+			"OK")
+
+		podFound = true
+	}
+	if !podFound {
+		showDatastoreClusterStatusError("DATASTORE_CLUSTER_NOT_FOUND")
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+	"strings"
+)
+
+func GetHostsStorage(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"HostSystem"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var hss []mo.HostSystem
+
+	err = v.RetrieveWithFilter(ctx, []string{"HostSystem"}, []string{"summary", "config.storageDevice"}, &hss, property.Match{"name": hostFlag})
+
+	if err != nil {
+		return err
+	}
+
+	switch sectionFlag {
+	case "luns":
+		fmt.Fprint(os.Stdout, "host;canonicalName;displayName;vendor;model;lunType;capacity;operationalState;multipathPolicy;numPaths;activePaths;standbyPaths;deadPaths\n")
+	case "paths":
+		fmt.Fprint(os.Stdout, "host;canonicalName;path;adapter;state;isWorkingPath\n")
+	default:
+		fmt.Fprint(os.Stdout, "host;device;type;model;driver;status;identifier\n")
+	}
+
+	hostFound := false
+	for _, hs := range hss {
+		hostFound = true
+		if hs.Config == nil || hs.Config.StorageDevice == nil {
+			continue
+		}
+		storage := hs.Config.StorageDevice
+
+		// paths reference adapters and LUNs by key
+		adapterNames := make(map[string]string)
+		for _, hba := range storage.HostBusAdapter {
+			adapterNames[hba.GetHostHostBusAdapter().Key] = hba.GetHostHostBusAdapter().Device
+		}
+		multipathLuns := make(map[string]types.HostMultipathInfoLogicalUnit)
+		if storage.MultipathInfo != nil {
+			for _, lun := range storage.MultipathInfo.Lun {
+				multipathLuns[lun.Lun] = lun
+			}
+		}
+
+		switch sectionFlag {
+		case "luns":
+			for _, scsiLun := range storage.ScsiLun {
+				lun := scsiLun.GetScsiLun()
+
+				capacity := int64(0)
+				if disk, ok := scsiLun.(*types.HostScsiDisk); ok {
+					capacity = disk.Capacity.Block * int64(disk.Capacity.BlockSize)
+				}
+
+				policy := "NA"
+				numPaths, activePaths, standbyPaths, deadPaths := 0, 0, 0, 0
+				if multipath, ok := multipathLuns[lun.Key]; ok {
+					if multipath.Policy != nil {
+						policy = multipath.Policy.GetHostMultipathInfoLogicalUnitPolicy().Policy
+					}
+					for _, path := range multipath.Path {
+						numPaths++
+						switch path.PathState {
+						case "active":
+							activePaths++
+						case "standby":
+							standbyPaths++
+						case "dead":
+							deadPaths++
+						}
+					}
+				}
+
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%d;%s;%s;%d;%d;%d;%d\n",
+					safeValue(hs.Summary.Config.Name),
+					safeValue(lun.CanonicalName),
+					safeValue(lun.DisplayName),
+					safeValue(strings.TrimSpace(lun.Vendor)),
+					safeValue(strings.TrimSpace(lun.Model)),
+					safeValue(lun.LunType),
+					capacity,
+					safeValue(strings.Join(lun.OperationalState, ",")),
+					safeValue(policy),
+					numPaths,
+					activePaths,
+					standbyPaths,
+					deadPaths,
+				)
+			}
+		case "paths":
+			for _, scsiLun := range storage.ScsiLun {
+				lun := scsiLun.GetScsiLun()
+				multipath, ok := multipathLuns[lun.Key]
+				if !ok {
+					continue
+				}
+				for _, path := range multipath.Path {
+					isWorkingPath := "NA"
+					if path.IsWorkingPath != nil {
+						isWorkingPath = fmt.Sprintf("%t", *path.IsWorkingPath)
+					}
+					fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s\n",
+						safeValue(hs.Summary.Config.Name),
+						safeValue(lun.CanonicalName),
+						safeValue(path.Name),
+						safeValue(adapterNames[path.Adapter]),
+						safeValue(path.PathState),
+						safeValue(isWorkingPath),
+					)
+				}
+			}
+		default:
+			for _, hba := range storage.HostBusAdapter {
+				adapter := hba.GetHostHostBusAdapter()
+				hbaType, identifier := hbaIdentifier(hba)
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%s\n",
+					safeValue(hs.Summary.Config.Name),
+					safeValue(adapter.Device),
+					safeValue(hbaType),
+					safeValue(adapter.Model),
+					safeValue(adapter.Driver),
+					safeValue(adapter.Status),
+					safeValue(identifier),
+				)
+			}
+		}
+	}
+	if !hostFound {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Host not found.")
+		os.Exit(1)
+	}
+	return nil
+}
+
+// hbaIdentifier returns the adapter type and its WWN (Fibre Channel) or IQN (iSCSI)
+func hbaIdentifier(hba types.BaseHostHostBusAdapter) (string, string) {
+	switch h := hba.(type) {
+	case *types.HostFibreChannelOverEthernetHba:
+		return "fcoe", formatWWN(h.PortWorldWideName)
+	case *types.HostFibreChannelHba:
+		return "fibreChannel", formatWWN(h.PortWorldWideName)
+	case *types.HostInternetScsiHba:
+		return "iscsi", h.IScsiName
+	case *types.HostBlockHba:
+		return "block", "NA"
+	case *types.HostParallelScsiHba:
+		return "parallelScsi", "NA"
+	case *types.HostSerialAttachedHba:
+		return "sas", h.NodeWorldWideName
+	default:
+		return hba.GetHostHostBusAdapter().StorageProtocol, "NA"
+	}
+}
+
+// formatWWN formats a world wide name as colon separated hex bytes
+func formatWWN(wwn int64) string {
+	hex := fmt.Sprintf("%016x", uint64(wwn))
+	var parts []string
+	for i := 0; i < len(hex); i += 2 {
+		parts = append(parts, hex[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
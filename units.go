@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math"
+	"strconv"
+)
+
+// unitConversion scales a value stored in a vSphere unit to its canonical base unit
+type unitConversion struct {
+	factor float64
+	unit   string
+}
+
+// unitConversions are keyed by the counter UnitInfo key. Percentages stored in hundredths are
+// already scaled when the series values are formatted, storage units are binary multiples while
+// network bit rates are decimal ones.
+var unitConversions = map[string]unitConversion{
+	"percent":            {factor: 1, unit: "%"},
+	"kiloBytes":          {factor: 1 << 10, unit: "B"},
+	"megaBytes":          {factor: 1 << 20, unit: "B"},
+	"gigaBytes":          {factor: 1 << 30, unit: "B"},
+	"teraBytes":          {factor: 1 << 40, unit: "B"},
+	"kiloBytesPerSecond": {factor: 1 << 10, unit: "Bps"},
+	"megaBytesPerSecond": {factor: 1 << 20, unit: "Bps"},
+	"kiloBitsPerSecond":  {factor: 1e3, unit: "bps"},
+	"megaBitsPerSecond":  {factor: 1e6, unit: "bps"},
+	"kiloHertz":          {factor: 1e3, unit: "Hz"},
+	"megaHertz":          {factor: 1e6, unit: "Hz"},
+	"gigaHertz":          {factor: 1e9, unit: "Hz"},
+	"nanosecond":         {factor: 1e-9, unit: "s"},
+	"microsecond":        {factor: 1e-6, unit: "s"},
+	"millisecond":        {factor: 1e-3, unit: "s"},
+	"second":             {factor: 1, unit: "s"},
+}
+
+// normalizeRow converts the values of a row to the canonical unit of its unit key,
+// rows with units without a conversion are left untouched
+func normalizeRow(row statsRow) statsRow {
+	conversion, ok := unitConversions[row.unitKey]
+	if !ok {
+		return row
+	}
+	values := make([]float64, len(row.values))
+	for i, value := range row.values {
+		values[i] = value * conversion.factor
+	}
+	return statsRow{instance: row.instance, values: values, unit: conversion.unit, unitKey: row.unitKey}
+}
+
+// formatNormalized prints a normalized value without the fixed two decimals, so small
+// values such as latencies in seconds keep their precision
+func formatNormalized(value float64) string {
+	return strconv.FormatFloat(math.Round(value*1e6)/1e6, 'f', -1, 64)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnitConversions(t *testing.T) {
+	// one value of each unit key and its expected canonical value
+	tests := []struct {
+		unitKey string
+		value   float64
+		want    float64
+		unit    string
+	}{
+		// percent series are already divided by 100 when formatted with ValueCSV
+		{unitKey: "percent", value: 12.34, want: 12.34, unit: "%"},
+		{unitKey: "kiloBytes", value: 2, want: 2048, unit: "B"},
+		{unitKey: "megaBytes", value: 2, want: 2097152, unit: "B"},
+		{unitKey: "gigaBytes", value: 2, want: 2147483648, unit: "B"},
+		{unitKey: "teraBytes", value: 2, want: 2199023255552, unit: "B"},
+		{unitKey: "kiloBytesPerSecond", value: 2, want: 2048, unit: "Bps"},
+		{unitKey: "megaBytesPerSecond", value: 2, want: 2097152, unit: "Bps"},
+		{unitKey: "kiloBitsPerSecond", value: 2, want: 2000, unit: "bps"},
+		{unitKey: "megaBitsPerSecond", value: 2, want: 2000000, unit: "bps"},
+		{unitKey: "kiloHertz", value: 2, want: 2000, unit: "Hz"},
+		{unitKey: "megaHertz", value: 2, want: 2000000, unit: "Hz"},
+		{unitKey: "gigaHertz", value: 2, want: 2000000000, unit: "Hz"},
+		{unitKey: "nanosecond", value: 2, want: 0.000000002, unit: "s"},
+		{unitKey: "microsecond", value: 2, want: 0.000002, unit: "s"},
+		{unitKey: "millisecond", value: 2, want: 0.002, unit: "s"},
+		{unitKey: "second", value: 2, want: 2, unit: "s"},
+	}
+
+	if len(tests) != len(unitConversions) {
+		t.Errorf("%d unit keys tested, %d conversions defined", len(tests), len(unitConversions))
+	}
+	for _, tt := range tests {
+		t.Run(tt.unitKey, func(t *testing.T) {
+			got := normalizeRow(statsRow{instance: "vmnic0", values: []float64{tt.value}, unit: "x", unitKey: tt.unitKey})
+			want := statsRow{instance: "vmnic0", values: []float64{tt.want}, unit: tt.unit, unitKey: tt.unitKey}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("normalizeRow() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestNormalizeRowWithoutConversion(t *testing.T) {
+	row := statsRow{values: []float64{5}, unit: "num", unitKey: "number"}
+	if got := normalizeRow(row); !reflect.DeepEqual(got, row) {
+		t.Errorf("normalizeRow() = %+v, want the row untouched", got)
+	}
+}
+
+func TestFormatNormalized(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{value: 2048, want: "2048"},
+		{value: 12.5, want: "12.5"},
+		{value: 0.002, want: "0.002"},
+		{value: 0.0000004, want: "0"},
+		{value: 1.23456789, want: "1.234568"},
+	}
+
+	for _, tt := range tests {
+		if got := formatNormalized(tt.value); got != tt.want {
+			t.Errorf("formatNormalized(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+)
+
+func getVApps(ctx context.Context, c *vim25.Client, props []string) ([]mo.VirtualApp, error) {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"VirtualApp"}, true)
+	if err != nil {
+		return nil, err
+	}
+	defer v.Destroy(ctx)
+
+	var vapps []mo.VirtualApp
+	err = v.RetrieveWithFilter(ctx, []string{"VirtualApp"}, props, &vapps, property.Match{"name": vappFlag})
+	if err != nil {
+		return nil, err
+	}
+	return vapps, nil
+}
+
+// vappState returns the vApp running state and its product info, if any
+func vappState(vapp mo.VirtualApp) (string, *types.VAppProductInfo) {
+	summary, ok := vapp.Summary.(*types.VirtualAppSummary)
+	if !ok {
+		return "NA", nil
+	}
+	return string(summary.VAppState), summary.Product
+}
+
+func GetVAppStatus(ctx context.Context, c *vim25.Client) error {
+	vapps, err := getVApps(ctx, c, []string{"name", "summary", "vm", "overallStatus"})
+	if err != nil {
+		showVAppStatusError(err.Error())
+	}
+
+	pc := property.DefaultCollector(c)
+	vappFound := false
+
+	fmt.Fprint(os.Stdout, "name;internalName;vAppState;numVms;numVmsPoweredOn;numVmsPoweredOff;numVmsSuspended;overallStatus;proxyStatus\n")
+	for _, vapp := range vapps {
+		poweredOn, poweredOff, suspended := 0, 0, 0
+		if len(vapp.Vm) > 0 {
+			var vms []mo.VirtualMachine
+			err = pc.Retrieve(ctx, vapp.Vm, []string{"summary.runtime.powerState"}, &vms)
+			if err != nil {
+				showVAppStatusError(err.Error())
+			}
+			for _, vm := range vms {
+				switch vm.Summary.Runtime.PowerState {
+				case types.VirtualMachinePowerStatePoweredOn:
+					poweredOn++
+				case types.VirtualMachinePowerStatePoweredOff:
+					poweredOff++
+				case types.VirtualMachinePowerStateSuspended:
+					suspended++
+				}
+			}
+		}
+
+		state, _ := vappState(vapp)
+		fmt.Fprintf(os.Stdout, "%s;%s;%s;%d;%d;%d;%d;%s;%s\n",
+			safeValue(vapp.Name),
+			safeValue(vapp.Self.Value),
+			safeValue(state),
+			len(vapp.Vm),
+			poweredOn,
+			poweredOff,
+			suspended,
+			safeValue(vapp.OverallStatus),
+			"OK")
+
+		vappFound = true
+	}
+	if !vappFound {
+		showVAppStatusError("VAPP_NOT_FOUND")
+	}
+	return nil
+}
+
+func GetVAppConfig(ctx context.Context, c *vim25.Client) error {
+	vapps, err := getVApps(ctx, c, []string{"name", "summary", "vm", "vAppConfig", "parent", "parentVApp"})
+	if err != nil {
+		return err
+	}
+	if len(vapps) == 0 {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "vApp not found.")
+		os.Exit(1)
+	}
+
+	vmNames, err := getEntityNames(ctx, c, "VirtualMachine")
+	if err != nil {
+		return err
+	}
+
+	if sectionFlag == "entities" {
+		fmt.Fprint(os.Stdout, "vapp;entity;internalName;tag;startOrder;startDelay;startAction;waitingForGuest;stopDelay;stopAction\n")
+	} else {
+		fmt.Fprint(os.Stdout, "name;internalName;parent;parentVApp;vAppState;product;vendor;version;fullVersion;vendorUrl;productUrl;numVms;vms\n")
+	}
+
+	for _, vapp := range vapps {
+		if sectionFlag == "entities" {
+			if vapp.VAppConfig == nil {
+				continue
+			}
+			for _, entity := range vapp.VAppConfig.EntityConfig {
+				entityName, entityValue := "NA", "NA"
+				if entity.Key != nil {
+					entityValue = entity.Key.Value
+					entityName = entity.Key.Value
+					if name, ok := vmNames[entity.Key.Value]; ok {
+						entityName = name
+					}
+				}
+				waitingForGuest := "NA"
+				if entity.WaitingForGuest != nil {
+					waitingForGuest = fmt.Sprintf("%t", *entity.WaitingForGuest)
+				}
+				fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%d;%d;%s;%s;%d;%s\n",
+					safeValue(vapp.Name),
+					safeValue(entityName),
+					safeValue(entityValue),
+					safeValue(entity.Tag),
+					entity.StartOrder,
+					entity.StartDelay,
+					safeValue(entity.StartAction),
+					waitingForGuest,
+					entity.StopDelay,
+					safeValue(entity.StopAction),
+				)
+			}
+			continue
+		}
+
+		parent, parentVApp := "NA", "NA"
+		if vapp.Parent != nil {
+			parent = vapp.Parent.Value
+		}
+		if vapp.ParentVApp != nil {
+			parentVApp = vapp.ParentVApp.Value
+		}
+
+		state, product := vappState(vapp)
+		if product == nil {
+			product = &types.VAppProductInfo{Name: "NA", Vendor: "NA", Version: "NA", FullVersion: "NA", VendorUrl: "NA", ProductUrl: "NA"}
+		}
+
+		fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%s;%s;%s;%s;%s;%d;%s\n",
+			safeValue(vapp.Name),
+			safeValue(vapp.Self.Value),
+			safeValue(parent),
+			safeValue(parentVApp),
+			safeValue(state),
+			safeValue(product.Name),
+			safeValue(product.Vendor),
+			safeValue(product.Version),
+			safeValue(product.FullVersion),
+			safeValue(product.VendorUrl),
+			safeValue(product.ProductUrl),
+			len(vapp.Vm),
+			safeValue(joinEntityNames(vapp.Vm, vmNames)),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+	"sort"
+	"strings"
+)
+
+func getHostImageConfigManagers(ctx context.Context, c *vim25.Client, name string) ([]mo.HostSystem, error) {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"HostSystem"}, true)
+	if err != nil {
+		return nil, err
+	}
+	defer v.Destroy(ctx)
+
+	var hss []mo.HostSystem
+	err = v.RetrieveWithFilter(ctx, []string{"HostSystem"}, []string{"name", "summary.config.product", "configManager.imageConfigManager"}, &hss, property.Match{"name": name})
+	if err != nil {
+		return nil, err
+	}
+	return hss, nil
+}
+
+func getHostSoftwarePackages(ctx context.Context, c *vim25.Client, hs mo.HostSystem) ([]types.SoftwarePackage, error) {
+	if hs.ConfigManager.ImageConfigManager == nil {
+		return nil, nil
+	}
+	res, err := methods.FetchSoftwarePackages(ctx, c, &types.FetchSoftwarePackages{This: *hs.ConfigManager.ImageConfigManager})
+	if err != nil {
+		return nil, err
+	}
+	packages := res.Returnval
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Name < packages[j].Name })
+	return packages, nil
+}
+
+// getHostImageProfile returns the image profile name and vendor, the acceptance level and the install date
+func getHostImageProfile(ctx context.Context, c *vim25.Client, ref types.ManagedObjectReference) (string, string, string, string, error) {
+	res, err := methods.HostImageConfigGetProfile(ctx, c, &types.HostImageConfigGetProfile{This: ref})
+	if err != nil {
+		return "", "", "", "", err
+	}
+	acceptanceRes, err := methods.HostImageConfigGetAcceptance(ctx, c, &types.HostImageConfigGetAcceptance{This: ref})
+	if err != nil {
+		return "", "", "", "", err
+	}
+	installRes, err := methods.InstallDate(ctx, c, &types.InstallDate{This: ref})
+	if err != nil {
+		return "", "", "", "", err
+	}
+	return res.Returnval.Name, res.Returnval.Vendor, acceptanceRes.Returnval, installRes.Returnval.Format("2006-01-02 15:04:05"), nil
+}
+
+func GetHostsSoftware(ctx context.Context, c *vim25.Client) error {
+	hss, err := getHostImageConfigManagers(ctx, c, hostFlag)
+	if err != nil {
+		return err
+	}
+	if len(hss) == 0 {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Host not found.")
+		os.Exit(1)
+	}
+
+	if sectionFlag == "profile" {
+		fmt.Fprint(os.Stdout, "host;imageProfile;vendor;acceptanceLevel;installDate;version;build;error\n")
+	} else {
+		fmt.Fprint(os.Stdout, "host;name;version;vendor;type;acceptanceLevel;creationDate;summary\n")
+	}
+
+	for _, hs := range hss {
+		if sectionFlag == "profile" {
+			profile, vendor, acceptance, installDate, profileError := "NA", "NA", "NA", "NA", ""
+			if hs.ConfigManager.ImageConfigManager != nil {
+				var err error
+				profile, vendor, acceptance, installDate, err = getHostImageProfile(ctx, c, *hs.ConfigManager.ImageConfigManager)
+				// a host that cannot answer gets an error row, the other hosts are still listed
+				if err != nil {
+					profile, vendor, acceptance, installDate = "NA", "NA", "NA", "NA"
+					profileError = "ERROR:" + strings.ReplaceAll(err.Error(), ";", ",")
+				}
+			}
+			fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%s;%s\n",
+				safeValue(hs.Name),
+				safeValue(profile),
+				safeValue(vendor),
+				safeValue(acceptance),
+				safeValue(installDate),
+				safeValue(hs.Summary.Config.Product.Version),
+				safeValue(hs.Summary.Config.Product.Build),
+				safeValue(profileError),
+			)
+			continue
+		}
+
+		packages, err := getHostSoftwarePackages(ctx, c, hs)
+		if err != nil {
+			return err
+		}
+		for _, p := range packages {
+			fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s;%s;%s\n",
+				safeValue(hs.Name),
+				safeValue(p.Name),
+				safeValue(p.Version),
+				safeValue(p.Vendor),
+				safeValue(p.Type),
+				safeValue(p.AcceptanceLevel),
+				safeValue(p.CreationDate),
+				safeValue(p.Summary),
+			)
+		}
+	}
+	return nil
+}
+
+// GetHostsSoftwareDiff lists the packages that are missing or at a different version between two hosts
+func GetHostsSoftwareDiff(ctx context.Context, c *vim25.Client) error {
+	versions := make([]map[string]string, 2)
+	names := []string{hostFlag, diffHostFlag}
+	for i, name := range names {
+		hss, err := getHostImageConfigManagers(ctx, c, name)
+		if err != nil {
+			return err
+		}
+		if len(hss) != 1 {
+			fmt.Fprintf(os.Stderr, "\nError: %s\n", "Host "+name+" not found or not unique.")
+			os.Exit(1)
+		}
+		// without an image config manager every package would be reported as missing
+		if hss[0].ConfigManager.ImageConfigManager == nil {
+			fmt.Fprintf(os.Stderr, "\nError: %s\n", "Packages of host "+name+" cannot be listed, the host has no image config manager.")
+			os.Exit(1)
+		}
+		packages, err := getHostSoftwarePackages(ctx, c, hss[0])
+		if err != nil {
+			return err
+		}
+		versions[i] = make(map[string]string)
+		for _, p := range packages {
+			versions[i][p.Name] = p.Version
+		}
+	}
+
+	unique := make(map[string]bool)
+	var packageNames []string
+	for _, v := range versions {
+		for name := range v {
+			if !unique[name] {
+				unique[name] = true
+				packageNames = append(packageNames, name)
+			}
+		}
+	}
+	sort.Strings(packageNames)
+
+	fmt.Fprint(os.Stdout, "host;otherHost;name;version;otherVersion;difference\n")
+	for _, name := range packageNames {
+		version, ok := versions[0][name]
+		otherVersion, otherOk := versions[1][name]
+		difference := ""
+		switch {
+		case !ok:
+			version = "NA"
+			difference = "onlyInOtherHost"
+		case !otherOk:
+			otherVersion = "NA"
+			difference = "onlyInHost"
+		case version != otherVersion:
+			difference = "versionMismatch"
+		default:
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "%s;%s;%s;%s;%s;%s\n",
+			safeValue(hostFlag),
+			safeValue(diffHostFlag),
+			safeValue(name),
+			safeValue(version),
+			safeValue(otherVersion),
+			difference,
+		)
+	}
+	return nil
+}
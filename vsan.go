@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"os"
+	"strings"
+)
+
+// vsanHost holds the vSAN configuration and membership state of a cluster host
+type vsanHost struct {
+	name          string
+	enabled       bool
+	clusterUuid   string
+	nodeUuid      string
+	nodeState     string
+	health        string
+	diskGroups    int
+	cacheDisks    int
+	capacityDisks int
+	// statusError is the error of the host vSAN status query, the node state is unknown when set
+	statusError string
+}
+
+// member reports whether the host is an active member of the given vSAN cluster, which requires
+// a known master, backup or agent node state
+func (h vsanHost) member(clusterUuid string) bool {
+	if !h.enabled || h.clusterUuid != clusterUuid {
+		return false
+	}
+	switch strings.ToLower(h.nodeState) {
+	case "master", "backup", "agent":
+		return true
+	}
+	return false
+}
+
+func getClusterVsanHosts(ctx context.Context, c *vim25.Client, cluster mo.ClusterComputeResource) ([]vsanHost, error) {
+	var hosts []vsanHost
+	if len(cluster.Host) == 0 {
+		return hosts, nil
+	}
+
+	var hss []mo.HostSystem
+	pc := property.DefaultCollector(c)
+	err := pc.Retrieve(ctx, cluster.Host, []string{"name", "config.vsanHostConfig", "configManager.vsanSystem"}, &hss)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hs := range hss {
+		host := vsanHost{name: hs.Name, nodeState: "NA", health: "NA"}
+
+		if hs.Config != nil && hs.Config.VsanHostConfig != nil {
+			config := hs.Config.VsanHostConfig
+			host.enabled = config.Enabled != nil && *config.Enabled
+			if config.ClusterInfo != nil {
+				host.clusterUuid = config.ClusterInfo.Uuid
+				host.nodeUuid = config.ClusterInfo.NodeUuid
+			}
+			if config.StorageInfo != nil {
+				for _, mapping := range config.StorageInfo.DiskMapping {
+					host.diskGroups++
+					host.cacheDisks++
+					host.capacityDisks += len(mapping.NonSsd)
+				}
+			}
+		}
+
+		// the node state is only known by the host vSAN system
+		if host.enabled && hs.ConfigManager.VsanSystem != nil {
+			res, err := methods.QueryHostStatus(ctx, c, &types.QueryHostStatus{This: *hs.ConfigManager.VsanSystem})
+			if err != nil {
+				host.statusError = err.Error()
+			} else {
+				host.nodeState = res.Returnval.NodeState.State
+				host.health = res.Returnval.Health
+			}
+		}
+
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// getClusterVsanDatastore returns the vsan type datastore of the cluster, if any
+func getClusterVsanDatastore(ctx context.Context, c *vim25.Client, cluster mo.ClusterComputeResource) (*mo.Datastore, error) {
+	if len(cluster.Datastore) == 0 {
+		return nil, nil
+	}
+
+	var dss []mo.Datastore
+	pc := property.DefaultCollector(c)
+	err := pc.Retrieve(ctx, cluster.Datastore, []string{"summary"}, &dss)
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range dss {
+		if ds.Summary.Type == "vsan" {
+			return &ds, nil
+		}
+	}
+	return nil, nil
+}
+
+func clusterVsanConfig(cluster mo.ClusterComputeResource) (bool, string) {
+	config, ok := cluster.ConfigurationEx.(*types.ClusterConfigInfoEx)
+	if !ok || config.VsanConfigInfo == nil {
+		return false, ""
+	}
+	enabled := config.VsanConfigInfo.Enabled != nil && *config.VsanConfigInfo.Enabled
+	uuid := ""
+	if config.VsanConfigInfo.DefaultConfig != nil {
+		uuid = config.VsanConfigInfo.DefaultConfig.Uuid
+	}
+	return enabled, uuid
+}
+
+func GetClusterVsan(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"ClusterComputeResource"}, true)
+	if err != nil {
+		return err
+	}
+	defer v.Destroy(ctx)
+	var clusters []mo.ClusterComputeResource
+
+	err = v.RetrieveWithFilter(ctx, []string{"ClusterComputeResource"}, []string{"name", "configurationEx", "host", "datastore"}, &clusters, property.Match{"self.value": clusterFlag})
+
+	if err != nil {
+		return err
+	}
+
+	if sectionFlag == "hosts" {
+		fmt.Fprint(os.Stdout, "cluster;host;vsanEnabled;member;nodeUuid;nodeState;health;diskGroups;cacheDisks;capacityDisks;statusError\n")
+	} else {
+		fmt.Fprint(os.Stdout, "cluster;name;vsanEnabled;clusterUuid;numHosts;numDiskGroups;datastore;capacity;used;free\n")
+	}
+
+	clusterFound := false
+	for _, cluster := range clusters {
+		clusterFound = true
+		enabled, clusterUuid := clusterVsanConfig(cluster)
+
+		hosts, err := getClusterVsanHosts(ctx, c, cluster)
+		if err != nil {
+			return err
+		}
+
+		if sectionFlag == "hosts" {
+			for _, host := range hosts {
+				fmt.Fprintf(os.Stdout, "%s;%s;%v;%v;%s;%s;%s;%d;%d;%d;%s\n",
+					safeValue(cluster.Self.Value),
+					safeValue(host.name),
+					safeValue(host.enabled),
+					safeValue(host.member(clusterUuid)),
+					safeValue(host.nodeUuid),
+					safeValue(host.nodeState),
+					safeValue(host.health),
+					host.diskGroups,
+					host.cacheDisks,
+					host.capacityDisks,
+					safeValue(strings.ReplaceAll(host.statusError, ";", ",")),
+				)
+			}
+			continue
+		}
+
+		diskGroups := 0
+		for _, host := range hosts {
+			diskGroups += host.diskGroups
+		}
+
+		ds, err := getClusterVsanDatastore(ctx, c, cluster)
+		if err != nil {
+			return err
+		}
+		datastore, capacity, free := "NA", int64(0), int64(0)
+		if ds != nil {
+			datastore = ds.Summary.Name
+			capacity = ds.Summary.Capacity
+			free = ds.Summary.FreeSpace
+		}
+
+		fmt.Fprintf(os.Stdout, "%s;%s;%v;%s;%d;%d;%s;%d;%d;%d\n",
+			safeValue(cluster.Self.Value),
+			safeValue(cluster.Name),
+			safeValue(enabled),
+			safeValue(clusterUuid),
+			len(hosts),
+			diskGroups,
+			safeValue(datastore),
+			capacity,
+			capacity-free,
+			free,
+		)
+	}
+	if !clusterFound {
+		fmt.Fprintf(os.Stderr, "\nError: %s\n", "Cluster not found.")
+		os.Exit(1)
+	}
+	return nil
+}
+
+func GetClusterVsanStatus(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+	v, err := m.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"ClusterComputeResource"}, true)
+	if err != nil {
+		showVsanStatusError(err.Error())
+	}
+	defer v.Destroy(ctx)
+	var clusters []mo.ClusterComputeResource
+
+	err = v.RetrieveWithFilter(ctx, []string{"ClusterComputeResource"}, []string{"name", "configurationEx", "host", "datastore"}, &clusters, property.Match{"self.value": clusterFlag})
+	if err != nil {
+		showVsanStatusError(err.Error())
+	}
+
+	clusterFound := false
+
+	fmt.Fprint(os.Stdout, "cluster;vsanEnabled;numHosts;numMembers;numHostsNotInVsan;hostsNotInVsan;capacity;freeSpace;numHostsStatusError;hostsStatusError;proxyStatus\n")
+	for _, cluster := range clusters {
+		enabled, clusterUuid := clusterVsanConfig(cluster)
+
+		hosts, err := getClusterVsanHosts(ctx, c, cluster)
+		if err != nil {
+			showVsanStatusError(err.Error())
+		}
+
+		// hosts are only flagged when vSAN is enabled on the cluster, hosts whose status
+		// query failed are reported apart since their membership is unknown
+		members := 0
+		var notInVsan, statusError []string
+		for _, host := range hosts {
+			if host.statusError != "" {
+				statusError = append(statusError, host.name)
+			} else if host.member(clusterUuid) {
+				members++
+			} else if enabled {
+				notInVsan = append(notInVsan, host.name)
+			}
+		}
+
+		ds, err := getClusterVsanDatastore(ctx, c, cluster)
+		if err != nil {
+			showVsanStatusError(err.Error())
+		}
+		capacity, free := int64(0), int64(0)
+		if ds != nil {
+			capacity = ds.Summary.Capacity
+			free = ds.Summary.FreeSpace
+		}
+
+		fmt.Fprintf(os.Stdout, "%s;%v;%d;%d;%d;%s;%d;%d;%d;%s;%s\n",
+			safeValue(cluster.Self.Value),
+			safeValue(enabled),
+			len(hosts),
+			members,
+			len(notInVsan),
+			safeValue(strings.Join(notInVsan, ",")),
+			capacity,
+			free,
+			len(statusError),
+			safeValue(strings.Join(statusError, ",")),
+			"OK")
+
+		clusterFound = true
+	}
+	if !clusterFound {
+		showVsanStatusError("CLUSTER_NOT_FOUND")
+	}
+	return nil
+}